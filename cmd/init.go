@@ -0,0 +1,41 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package cmd
+
+import (
+	dues "github.com/anjolaoluwaakindipe/dues/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initOutput = "dues.json"
+	initForce  bool
+	initCmd    = &cobra.Command{
+		Use:   "init [directory]",
+		Short: "Create a starter dues config file for a project",
+		Long: `Detects the kind of project in the directory, by looking for files such as
+go.mod, package.json, Cargo.toml, Makefile and Procfile, and writes a starter
+config file with a command for each. An existing file is never overwritten
+unless --force is given.`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          initRun,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+)
+
+func init() {
+	initCmd.Flags().StringVarP(&initOutput, "output", "o", initOutput, "Config file to write. Its extension picks the format: .json, .yaml, .yml or .toml.")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite the config file if it already exists.")
+	rootCmd.AddCommand(initCmd)
+}
+
+// init command execution
+func initRun(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	return dues.InitConfig(dir, initOutput, initForce)
+}
@@ -0,0 +1,28 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package cmd
+
+import (
+	dues "github.com/anjolaoluwaakindipe/dues/pkg"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List the commands, groups and aliases of the dues config file",
+	Long:          `Lists every command, group and alias of the dues config file, including the ones from extended and included files, along with the file each command was defined in.`,
+	Args:          cobra.NoArgs,
+	RunE:          listRun,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+// list command execution
+func listRun(cmd *cobra.Command, args []string) error {
+	return dues.ListCommands(configPath, profile, cmd.OutOrStdout())
+}
@@ -4,6 +4,7 @@ Copyright © 2024 The Dues Authors
 package cmd
 
 import (
+	"errors"
 	"os"
 
 	"github.com/anjolaoluwaakindipe/dues/internal/log"
@@ -12,12 +13,16 @@ import (
 )
 
 var (
-	configPath = "dues.json"
+	configPath = ""
+	profile    = ""
+	tags       []string
+	all        bool
+	exitStatus = dues.ExitStatusFirst
 	rootCmd    = &cobra.Command{
-		Use:           "dues",
+		Use:           "dues [command, group or alias]...",
 		Short:         "A live reloading application made to handle multiple tasks concurrently",
 		Long:          ``,
-		Args:          cobra.MatchAll(cobra.MinimumNArgs(1)),
+		Args:          cobra.MatchAll(cobra.ArbitraryArgs),
 		RunE:          rootRun,
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -30,20 +35,33 @@ func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
 		log.Logger.Error(err.Error())
+
+		var exitErr *dues.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	rootCmd.Flags().StringVar(&configPath, "config", configPath, "Your dues config path.")
+	rootCmd.Flags().StringSliceVar(&tags, "tag", nil, "Run every command with this tag. Can be repeated.")
+	rootCmd.Flags().BoolVar(&all, "all", false, "Run every command in the config.")
+	rootCmd.Flags().StringVar(&exitStatus, "exit-status", exitStatus, "How the exit status is chosen when commands failed: first (code of the first failure) or max (highest code).")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", profile, "Profile to apply to the commands. Defaults to the DUES_PROFILE environment variable.")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", configPath, "Your dues config path. Defaults to the first dues config file found in the current directory or its parents.")
 }
 
 // root command execution
 func rootRun(cmd *cobra.Command, args []string) error {
 	config := dues.DuesConfig{
 		Commands:   args,
+		Tags:       tags,
+		All:        all,
 		ConfigPath: configPath,
+		Profile:    profile,
+		ExitStatus: exitStatus,
 	}
 
 	return dues.RunDues(config)
@@ -0,0 +1,50 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/config"
+	"github.com/anjolaoluwaakindipe/dues/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaOutput string
+	schemaCmd    = &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the dues config file",
+		Long: `Prints the JSON Schema of the dues config file so that editors can validate
+and autocomplete it. A copy is kept in dues.schema.json at the root of the
+repository.`,
+		Args:          cobra.NoArgs,
+		RunE:          schemaRun,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+)
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to this file instead of stdout.")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// schema command execution
+func schemaRun(cmd *cobra.Command, args []string) error {
+	generated := schema.Generate(config.UserConfig{}, "", "dues config")
+
+	data, err := json.MarshalIndent(generated, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if schemaOutput == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	return os.WriteFile(schemaOutput, data, 0644)
+}
@@ -0,0 +1,27 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package cmd
+
+import (
+	dues "github.com/anjolaoluwaakindipe/dues/pkg"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:           "show [command, group or alias]...",
+	Short:         "Print the effective definition of commands",
+	Long:          `Prints commands as they will actually run, with the selected profile applied and variables expanded. Every command is printed when none is given.`,
+	RunE:          showRun,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
+
+// show command execution
+func showRun(cmd *cobra.Command, args []string) error {
+	return dues.ShowCommands(configPath, profile, args, cmd.OutOrStdout())
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	dues "github.com/anjolaoluwaakindipe/dues/pkg"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the dues config file for mistakes",
+	Long: `Strictly checks the dues config file. Unknown keys, invalid commands, missing
+cwd directories, executables that cannot be found on PATH and invalid
+ignore/include patterns are all reported at once.`,
+	Args:          cobra.NoArgs,
+	RunE:          validateRun,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validate command execution
+func validateRun(cmd *cobra.Command, args []string) error {
+	path, problems := dues.ValidateConfig(configPath, profile)
+
+	for _, problem := range problems {
+		fmt.Fprintln(cmd.OutOrStdout(), problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s) in the config file", len(problems))
+	}
+
+	log.Logger.Info(fmt.Sprintf("Config file %v is valid", path))
+	return nil
+}
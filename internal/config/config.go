@@ -4,22 +4,55 @@ Copyright © 2024 The Dues Authors
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
-// Reads configuration file and populates User Config
+// Reads configuration file and populates User Config. The decoder is picked
+// from the file extension of path.
 func ReadConfigFile(path string, config *UserConfig) error {
-	jsonFile, err := os.Open(path)
+	return readConfigFile(path, config, false)
+}
+
+// ReadConfigFileStrict behaves like ReadConfigFile but also fails on keys that
+// are not part of the config, such as misspelled field names
+func ReadConfigFileStrict(path string, config *UserConfig) error {
+	return readConfigFile(path, config, true)
+}
 
+func readConfigFile(path string, config *UserConfig, strict bool) error {
+	decode, err := decoderFor(path)
 	if err != nil {
 		return err
 	}
-	defer jsonFile.Close()
 
-	err = json.NewDecoder(jsonFile).Decode(config)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return decode(path, data, config, strict)
+}
+
+// decoderFor returns the decoder responsible for the format of the config
+// file at path
+func decoderFor(path string) (decoder, error) {
+	ext := extension(path)
+	decode, exists := decoders[ext]
+	if !exists {
+		return nil, fmt.Errorf("unsupported config file extension '%v' in %v, expected one of %v", ext, path, strings.Join(SupportedExtensions(), ", "))
+	}
+	return decode, nil
+}
+
+// extension returns the lower cased file extension of path
+func extension(path string) string {
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// SupportedExtensions lists the config file extensions dues knows how to decode
+func SupportedExtensions() []string {
+	return []string{".json", ".yaml", ".yml", ".toml"}
 }
@@ -0,0 +1,329 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// decoder populates config from the raw contents of the file at path. A strict
+// decoder rejects keys that do not map to a config field
+type decoder func(path string, data []byte, config *UserConfig, strict bool) error
+
+var decoders = map[string]decoder{
+	".json": decodeJSON,
+	".yaml": decodeYAML,
+	".yml":  decodeYAML,
+	".toml": decodeTOML,
+}
+
+// DecodeError is returned when a config file could not be decoded. Line and
+// Column start at 1 and are zero when the underlying decoder does not report
+// them.
+type DecodeError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (de *DecodeError) Error() string {
+	position := de.File
+	if de.Line > 0 {
+		position += ":" + strconv.Itoa(de.Line)
+		if de.Column > 0 {
+			position += ":" + strconv.Itoa(de.Column)
+		}
+	}
+	return fmt.Sprintf("%v: %v", position, de.Message)
+}
+
+func decodeJSON(path string, data []byte, config *UserConfig, strict bool) error {
+	err := json.Unmarshal(data, config)
+	if err == nil {
+		if strict {
+			return unknownJSONFields(path, data)
+		}
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := lineAndColumn(data, syntaxErr.Offset)
+		return &DecodeError{File: path, Line: line, Column: column, Message: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		line, column := lineAndColumn(data, typeErr.Offset)
+		message := fmt.Sprintf("cannot use %v value for field '%v' of type %v", typeErr.Value, typeErr.Field, typeErr.Type)
+		return &DecodeError{File: path, Line: line, Column: column, Message: message}
+	}
+	return &DecodeError{File: path, Message: strings.TrimPrefix(err.Error(), "json: ")}
+}
+
+// unknownJSONFields reports every key of a json config that does not map to a
+// config field, one error per key. The json decoder can only report the first
+// one, so the document is decoded again without a schema and compared against
+// the fields of UserConfig.
+func unknownJSONFields(path string, data []byte) error {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return &DecodeError{File: path, Message: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+
+	var unknown []*DecodeError
+	for _, key := range unknownFields(document, reflect.TypeOf(UserConfig{}), "") {
+		// the decoded document does not keep track of where a key is, so the
+		// first occurrence of its name is used
+		name := key[strings.LastIndex(key, ".")+1:]
+		line, column := 0, 0
+		if offset := bytes.Index(data, []byte(strconv.Quote(name))); offset >= 0 {
+			line, column = lineAndColumn(data, int64(offset))
+		}
+		unknown = append(unknown, &DecodeError{File: path, Line: line, Column: column, Message: fmt.Sprintf("unknown field %q", key)})
+	}
+
+	slices.SortStableFunc(unknown, func(a, b *DecodeError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	errs := make([]error, len(unknown))
+	for i, err := range unknown {
+		errs[i] = err
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unknownFields walks a decoded json value alongside the type it was decoded
+// into and returns the dotted path of every object key without a matching
+// field. Like the json decoder, keys are matched without regard to case.
+// Values of types that decode themselves are not looked into.
+func unknownFields(value any, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return nil
+	}
+
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range utils.SortedKeys(object) {
+			field, known := jsonField(t, key)
+			if !known {
+				unknown = append(unknown, join(key))
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], field.Type, join(key))...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range utils.SortedKeys(object) {
+			unknown = append(unknown, unknownFields(object[key], t.Elem(), join(key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		array, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		for i, element := range array {
+			unknown = append(unknown, unknownFields(element, t.Elem(), path+"["+strconv.Itoa(i)+"]")...)
+		}
+	}
+	return unknown
+}
+
+// jsonField finds the field of struct type t that the json object key decodes into
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func decodeYAML(path string, data []byte, config *UserConfig, strict bool) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		line, message := splitLine(strings.TrimPrefix(err.Error(), "yaml: "))
+		return &DecodeError{File: path, Line: line, Column: firstColumn(data, line), Message: message}
+	}
+
+	// an empty document decodes into nothing
+	if root.Kind == 0 {
+		return nil
+	}
+
+	var err error
+	if strict {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(config)
+	} else {
+		err = root.Decode(config)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		errs := make([]error, len(typeErr.Errors))
+		for i, typeErrMessage := range typeErr.Errors {
+			line, message := splitLine(typeErrMessage)
+			errs[i] = &DecodeError{File: path, Line: line, Column: yamlColumn(&root, line), Message: message}
+		}
+		return errors.Join(errs...)
+	}
+	line, message := splitLine(strings.TrimPrefix(err.Error(), "yaml: "))
+	return &DecodeError{File: path, Line: line, Column: yamlColumn(&root, line), Message: message}
+}
+
+func decodeTOML(path string, data []byte, config *UserConfig, strict bool) error {
+	metaData, err := toml.Decode(string(data), config)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return &DecodeError{File: path, Line: parseErr.Position.Line, Column: parseErr.Position.Col, Message: parseErr.Message}
+		}
+		line, message := splitLine(strings.TrimPrefix(err.Error(), "toml: "))
+		return &DecodeError{File: path, Line: line, Column: firstColumn(data, line), Message: message}
+	}
+
+	if !strict {
+		return nil
+	}
+
+	var errs []error
+	var reported []string
+	for _, key := range metaData.Undecoded() {
+		// the keys of an unknown table are unknown as well
+		name := key.String()
+		if slices.ContainsFunc(reported, func(parent string) bool { return strings.HasPrefix(name, parent+".") }) {
+			continue
+		}
+		reported = append(reported, name)
+
+		line, column := tomlKeyPosition(data, key)
+		errs = append(errs, &DecodeError{File: path, Line: line, Column: column, Message: fmt.Sprintf("unknown field %q", key.String())})
+	}
+	return errors.Join(errs...)
+}
+
+// tomlKeyPosition finds the line and column where the last part of key is
+// defined, either as a table header or as a key/value pair
+func tomlKeyPosition(data []byte, key toml.Key) (int, int) {
+	if len(key) == 0 {
+		return 0, 0
+	}
+	name := regexp.QuoteMeta(key[len(key)-1])
+	definition := regexp.MustCompile(`(?m)^[ \t]*(?:\[+[^\]\n]*?)?"?` + name + `"?[ \t]*(?:=|\])`)
+	location := definition.FindIndex(data)
+	if location == nil {
+		return 0, 0
+	}
+	line, _ := lineAndColumn(data, int64(location[0]))
+	return line, firstColumn(data, line)
+}
+
+// lineAndColumn converts a byte offset in data into a 1 based line and column
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// firstColumn returns the column of the first non blank character on line. It
+// is used when a decoder only reports the line an error occurred on
+func firstColumn(data []byte, line int) int {
+	lines := bytes.Split(data, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	content := lines[line-1]
+	return len(content) - len(bytes.TrimLeft(content, " \t")) + 1
+}
+
+var linePrefix = regexp.MustCompile(`^line (\d+)(?: \(.*?\))?: `)
+
+// splitLine pulls the "line N: " prefix some decoders put in their messages
+// out into a separate line number
+func splitLine(message string) (int, string) {
+	match := linePrefix.FindStringSubmatch(message)
+	if match == nil {
+		return 0, message
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line, message[len(match[0]):]
+}
+
+// yamlColumn finds the column of the right most node on the given line, which
+// is the value a yaml type error complains about
+func yamlColumn(node *yaml.Node, line int) int {
+	if line == 0 {
+		return 0
+	}
+	column := 0
+	if node.Line == line && node.Column > column {
+		column = node.Column
+	}
+	for _, child := range node.Content {
+		if c := yamlColumn(child, line); c > column {
+			column = c
+		}
+	}
+	return column
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDecodeJSONReportsEveryUnknownField(t *testing.T) {
+	data := []byte(`{
+  "comands": {},
+  "commands": {
+    "api": {
+      "command": "go run .",
+      "Cwd": "api",
+      "comand": "x",
+      "ready": {"tcp": ":80", "tpc": ":81"},
+      "on": [{"match": ["*.go"], "acton": "run"}],
+      "debounce": "1s"
+    }
+  },
+  "profiles": {"dev": {"api": {"anything": 1}}}
+}`)
+
+	var config UserConfig
+	err := decodeJSON("dues.json", data, &config, true)
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		t.Fatalf("decodeJSON returned %v, want one error per unknown field", err)
+	}
+	var got []string
+	for _, err := range joined.Unwrap() {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`dues.json:2:3: unknown field "comands"`,
+		`dues.json:7:7: unknown field "commands.api.comand"`,
+		`dues.json:8:31: unknown field "commands.api.ready.tpc"`,
+		`dues.json:9:34: unknown field "commands.api.on[0].acton"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("decodeJSON errors = %q, want %q", got, want)
+	}
+
+	if err := decodeJSON("dues.json", data, &UserConfig{}, false); err != nil {
+		t.Errorf("non strict decodeJSON returned %v, want unknown fields to be ignored", err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// Validates the dependsOn field of every command. Dependencies have to be
+// existing commands and cannot form a cycle.
+func (uc *UserConfig) processDependencies() error {
+	var errs []error
+	for _, name := range utils.SortedKeys(uc.Commands) {
+		command := uc.Commands[name]
+		if command == nil {
+			continue
+		}
+		for _, dependency := range command.DependsOn {
+			if !uc.DoesCommandExist(dependency) {
+				errs = append(errs, errors.New(fmt.Sprintf("Command '%v' depends on command '%v' which does not exists in this config", name, dependency)))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return errors.New(fmt.Sprintf("Commands have a circular dependency: %v", strings.Join(cycle, " -> ")))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		if command := uc.Commands[name]; command != nil {
+			for _, dependency := range command.DependsOn {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range utils.SortedKeys(uc.Commands) {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withDependencies adds the dependencies of the named commands, recursively,
+// and orders the result so that every command comes after the commands it
+// depends on. Commands otherwise keep the order they were given in
+func (uc *UserConfig) withDependencies(names []string) []string {
+	var ordered []string
+	added := make(map[string]bool)
+
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		if command := uc.Commands[name]; command != nil {
+			for _, dependency := range command.DependsOn {
+				add(dependency)
+			}
+		}
+		ordered = append(ordered, name)
+	}
+
+	for _, name := range names {
+		add(name)
+	}
+	return ordered
+}
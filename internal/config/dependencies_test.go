@@ -0,0 +1,78 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// withDependsOn builds a config whose commands only have their dependsOn set
+func withDependsOn(dependencies map[string][]string) *UserConfig {
+	commands := make(map[string]*process.Command, len(dependencies))
+	for name, dependsOn := range dependencies {
+		commands[name] = &process.Command{DependsOn: dependsOn}
+	}
+	return &UserConfig{Commands: commands}
+}
+
+func TestProcessDependencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies map[string][]string
+		want         string
+	}{
+		{"no dependencies", map[string][]string{"a": nil, "b": nil}, ""},
+		{"chain", map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}, ""},
+		{"diamond", map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}, "d": nil}, ""},
+		{"self dependency", map[string][]string{"a": {"a"}}, "Commands have a circular dependency: a -> a"},
+		{"two commands", map[string][]string{"a": {"b"}, "b": {"a"}}, "Commands have a circular dependency: a -> b -> a"},
+		{"cycle behind a dependency", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"d"}, "d": {"b"}}, "Commands have a circular dependency: b -> c -> d -> b"},
+		{"unknown dependency", map[string][]string{"a": {"missing"}}, "Command 'a' depends on command 'missing' which does not exists in this config"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := withDependsOn(test.dependencies).processDependencies()
+			if test.want == "" {
+				if err != nil {
+					t.Errorf("processDependencies returned %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("processDependencies returned %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestWithDependencies(t *testing.T) {
+	config := withDependsOn(map[string][]string{
+		"web":    {"api"},
+		"api":    {"db", "cache"},
+		"worker": {"db"},
+		"db":     nil,
+		"cache":  nil,
+	})
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"db"}, []string{"db"}},
+		{[]string{"web"}, []string{"db", "cache", "api", "web"}},
+		{[]string{"worker", "web"}, []string{"db", "worker", "cache", "api", "web"}},
+		{[]string{"api", "api"}, []string{"db", "cache", "api"}},
+	}
+
+	for _, test := range tests {
+		if got := config.withDependencies(test.names); !slices.Equal(got, test.want) {
+			t.Errorf("withDependencies(%q) = %q, want %q", test.names, got, test.want)
+		}
+	}
+}
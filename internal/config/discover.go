@@ -0,0 +1,41 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFileNames are the file names dues looks for when no config path is
+// given, in order of preference
+var ConfigFileNames = []string{"dues.json", "dues.yaml", "dues.yml", "dues.toml"}
+
+// FindConfigFile searches dir and then each of its parents for a file named
+// in ConfigFileNames, the same way git finds the .git directory. The absolute
+// path of the first file found is returned.
+func FindConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for current := dir; ; {
+		for _, name := range ConfigFileNames {
+			candidate := filepath.Join(current, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return "", fmt.Errorf("could not find any of %v in %v or its parent directories", ConfigFileNames, dir)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// encoder serializes v in the format of a config file
+type encoder func(v any) ([]byte, error)
+
+var encoders = map[string]encoder{
+	".json": encodeJSON,
+	".yaml": encodeYAML,
+	".yml":  encodeYAML,
+	".toml": encodeTOML,
+}
+
+// EncodeConfigFile serializes v in the format picked from the file extension
+// of path
+func EncodeConfigFile(path string, v any) ([]byte, error) {
+	if _, err := decoderFor(path); err != nil {
+		return nil, err
+	}
+	return encoders[extension(path)](v)
+}
+
+func encodeJSON(v any) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func encodeYAML(v any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodeTOML(v any) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := toml.NewEncoder(&buffer).Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// NamespaceSeparator joins the namespace of an included command to its name
+const NamespaceSeparator = ":"
+
+// varScope holds the vars of the file a command was defined in
+type varScope struct {
+	configPath string
+	vars       map[string]string
+}
+
+// Load reads the config file at path together with every file it extends or
+// includes, recursively. The files are merged in this order, later ones
+// overriding earlier ones:
+//
+//  1. the files listed in extends, in order
+//  2. the file itself
+//  3. the files listed in include, whose commands, groups and aliases are
+//     namespaced with the name of the directory they came from, e.g. billing:api
+//
+// A command defined in several files is replaced as a whole while groups,
+// aliases and vars are merged key by key. Commands keep track of the file they were
+// defined in, so that their cwd is resolved against that file's directory.
+func Load(path string) (*UserConfig, error) {
+	return load(path, false, nil)
+}
+
+// LoadStrict behaves like Load but rejects unknown keys in every file
+func LoadStrict(path string) (*UserConfig, error) {
+	return load(path, true, nil)
+}
+
+func load(path string, strict bool, stack []string) (*UserConfig, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, path) {
+		return nil, fmt.Errorf("config files form a cycle: %v", strings.Join(append(stack, path), " -> "))
+	}
+	stack = append(stack, path)
+
+	var file UserConfig
+	if err := readConfigFile(path, &file, strict); err != nil {
+		return nil, err
+	}
+	for _, command := range file.Commands {
+		if command != nil && command.Source == "" {
+			command.Source = path
+		}
+	}
+
+	merged := &UserConfig{Schema: file.Schema, path: path}
+	for _, base := range file.Extends {
+		basePath, err := resolveConfigReference(path, base)
+		if err != nil {
+			return nil, err
+		}
+		baseConfig, err := load(basePath, strict, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged.override(baseConfig)
+	}
+	file.files = []string{path}
+	merged.override(&file)
+
+	for _, include := range file.Include {
+		includePath, err := resolveConfigReference(path, include)
+		if err != nil {
+			return nil, err
+		}
+		included, err := load(includePath, strict, stack)
+		if err != nil {
+			return nil, err
+		}
+		if err := merged.addNamespaced(included, filepath.Base(filepath.Dir(includePath))); err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// resolveConfigReference resolves a path listed in extends or include of the
+// config file at from. References to directories point to the config file
+// inside of them.
+func resolveConfigReference(from string, reference string) (string, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return "", fmt.Errorf("%v: empty path in extends or include", from)
+	}
+	if !filepath.IsAbs(reference) {
+		reference = filepath.Join(filepath.Dir(from), reference)
+	}
+
+	info, err := os.Stat(reference)
+	if err != nil {
+		return "", fmt.Errorf("%v: could not find config file %v", from, reference)
+	}
+	if !info.IsDir() {
+		return reference, nil
+	}
+
+	for _, name := range ConfigFileNames {
+		candidate := filepath.Join(reference, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%v: could not find any of %v in %v", from, ConfigFileNames, reference)
+}
+
+// override merges other into the config, with other taking precedence
+func (uc *UserConfig) override(other *UserConfig) {
+	if uc.Commands == nil {
+		uc.Commands = make(map[string]*process.Command)
+	}
+	if uc.Groups == nil {
+		uc.Groups = make(map[string][]string)
+	}
+	if uc.Aliases == nil {
+		uc.Aliases = make(map[string]string)
+	}
+	if uc.Vars == nil {
+		uc.Vars = make(map[string]string)
+	}
+	if uc.varScopes == nil {
+		uc.varScopes = make(map[string]*varScope)
+	}
+
+	for name, command := range other.Commands {
+		uc.Commands[name] = command
+		delete(uc.varScopes, name)
+		if scope, scoped := other.varScopes[name]; scoped {
+			uc.varScopes[name] = scope
+		}
+	}
+	for name, members := range other.Groups {
+		uc.Groups[name] = members
+	}
+	for name, target := range other.Aliases {
+		uc.Aliases[name] = target
+	}
+	for name, value := range other.Vars {
+		uc.Vars[name] = value
+	}
+	for name, profile := range other.Profiles {
+		uc.addProfileEntries(name, profile)
+	}
+	uc.files = append(uc.files, other.files...)
+}
+
+// addNamespaced adds the commands, groups and aliases of an included config, prefixing
+// their names with namespace. The included commands keep resolving variables
+// against the vars of the file they came from.
+func (uc *UserConfig) addNamespaced(included *UserConfig, namespace string) error {
+	prefix := namespace + NamespaceSeparator
+	scope := &varScope{configPath: included.path, vars: included.Vars}
+
+	for name, command := range included.Commands {
+		namespaced := prefix + name
+		if uc.DoesCommandExist(namespaced) {
+			return errors.New(fmt.Sprintf("Command '%v' from %v is already defined", namespaced, included.path))
+		}
+		if command != nil {
+			for i, dependency := range command.DependsOn {
+				command.DependsOn[i] = prefix + dependency
+			}
+		}
+		uc.Commands[namespaced] = command
+		if inner, scoped := included.varScopes[name]; scoped {
+			uc.varScopes[namespaced] = inner
+		} else {
+			uc.varScopes[namespaced] = scope
+		}
+	}
+
+	for name, members := range included.Groups {
+		namespaced := prefix + name
+		if uc.DoesGroupExist(namespaced) {
+			return errors.New(fmt.Sprintf("Group '%v' from %v is already defined", namespaced, included.path))
+		}
+		prefixed := make([]string, len(members))
+		for i, member := range members {
+			prefixed[i] = prefix + member
+		}
+		uc.Groups[namespaced] = prefixed
+	}
+
+	for name, target := range included.Aliases {
+		namespaced := prefix + name
+		if uc.DoesAliasExist(namespaced) {
+			return errors.New(fmt.Sprintf("Alias '%v' from %v is already defined", namespaced, included.path))
+		}
+		uc.Aliases[namespaced] = prefix + target
+	}
+
+	// profile entries of the included file only apply to its own commands
+	for name, profile := range included.Profiles {
+		namespacedProfile := make(Profile)
+		for command := range included.Commands {
+			if overrides := profile.overrides(command); len(overrides) > 0 {
+				namespacedProfile[prefix+command] = overrides
+			}
+		}
+		for command, overrides := range profile {
+			if command != AllCommands && !included.DoesCommandExist(command) {
+				namespacedProfile[prefix+command] = overrides
+			}
+		}
+		uc.addProfileEntries(name, namespacedProfile)
+	}
+
+	uc.files = append(uc.files, included.files...)
+	return nil
+}
+
+// addProfileEntries merges the entries of profile into the profile of the
+// same name, replacing the entries of commands defined in both
+func (uc *UserConfig) addProfileEntries(name string, profile Profile) {
+	if uc.Profiles == nil {
+		uc.Profiles = make(map[string]Profile)
+	}
+	if uc.Profiles[name] == nil {
+		uc.Profiles[name] = make(Profile)
+	}
+	for command, overrides := range profile {
+		uc.Profiles[name][command] = overrides
+	}
+}
+
+// Path returns the config file the config was loaded from
+func (uc *UserConfig) Path() string {
+	return uc.path
+}
+
+// Files lists every config file the config was loaded from, including the
+// files it extends and includes
+func (uc *UserConfig) Files() []string {
+	return uc.files
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// AllCommands is the key of a profile entry that applies to every command
+const AllCommands = "*"
+
+// Profile holds partial command definitions keyed by command name. Each entry
+// uses the same field names as a command and only has to list the fields it
+// changes. The entry under AllCommands applies to every command before the
+// command's own entry.
+type Profile map[string]map[string]any
+
+// overrides returns the combined overrides the profile has for command
+func (p Profile) overrides(command string) map[string]any {
+	combined := make(map[string]any)
+	process.MergeFields(combined, deepCopy(p[AllCommands]))
+	process.MergeFields(combined, deepCopy(p[command]))
+	return combined
+}
+
+// Checks whether profile exists in configuration
+func (uc *UserConfig) DoesProfileExist(profile string) bool {
+	_, exists := uc.Profiles[profile]
+	return exists
+}
+
+// ApplyProfile overlays the named profile on the commands. It is meant to be
+// called before Process, and does nothing when name is empty
+func (uc *UserConfig) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	profile, exists := uc.Profiles[name]
+	if !exists {
+		return errors.New(fmt.Sprintf("Profile '%v' does not exists in this config", name))
+	}
+
+	for _, command := range utils.SortedKeys(profile) {
+		if command != AllCommands && !uc.DoesCommandExist(command) {
+			return errors.New(fmt.Sprintf("Profile '%v' overrides command '%v' which does not exists in this config", name, command))
+		}
+	}
+
+	var errs []error
+	for _, commandName := range utils.SortedKeys(uc.Commands) {
+		command := uc.Commands[commandName]
+		overrides := profile.overrides(commandName)
+		if command == nil || len(overrides) == 0 {
+			continue
+		}
+
+		command.Name = commandName
+		overridden, err := command.Override(overrides)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile '%v': %w", name, err))
+			continue
+		}
+		uc.Commands[commandName] = overridden
+	}
+	return errors.Join(errs...)
+}
+
+// deepCopy copies overrides so that merging into the copy leaves the profile
+// untouched
+func deepCopy(overrides map[string]any) map[string]any {
+	if overrides == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(overrides))
+	for key, value := range overrides {
+		if object, isObject := value.(map[string]any); isObject {
+			value = deepCopy(object)
+		}
+		copied[key] = value
+	}
+	return copied
+}
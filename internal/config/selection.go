@@ -0,0 +1,134 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// Selection describes which commands of a UserConfig should be run. Names may
+// refer to commands, groups or aliases of either.
+type Selection struct {
+	Names []string
+	Tags  []string
+	All   bool
+}
+
+// IsEmpty reports whether the selection would not select any command
+func (s Selection) IsEmpty() bool {
+	return !s.All && len(s.Names) == 0 && len(s.Tags) == 0
+}
+
+// Validates the groups field. Every group member has to be an existing command
+// and a group cannot share its name with a command.
+func (uc *UserConfig) processGroups() error {
+	for _, group := range utils.SortedKeys(uc.Groups) {
+		if uc.DoesCommandExist(group) {
+			return errors.New(fmt.Sprintf("Group '%v' has the same name as a command", group))
+		}
+
+		members := uc.Groups[group]
+		if len(members) == 0 {
+			return errors.New(fmt.Sprintf("Group '%v' has no commands", group))
+		}
+
+		for _, member := range members {
+			if !uc.DoesCommandExist(member) {
+				return errors.New(fmt.Sprintf("Group '%v' references command '%v' which does not exists in this config", group, member))
+			}
+		}
+	}
+	return nil
+}
+
+// Validates the aliases field. An alias cannot share its name with a command
+// or group and has to refer to an existing command or group.
+func (uc *UserConfig) processAliases() error {
+	for _, alias := range utils.SortedKeys(uc.Aliases) {
+		if uc.DoesCommandExist(alias) {
+			return errors.New(fmt.Sprintf("Alias '%v' has the same name as a command", alias))
+		}
+		if uc.DoesGroupExist(alias) {
+			return errors.New(fmt.Sprintf("Alias '%v' has the same name as a group", alias))
+		}
+
+		target := uc.Aliases[alias]
+		if !uc.DoesCommandExist(target) && !uc.DoesGroupExist(target) {
+			return errors.New(fmt.Sprintf("Alias '%v' references command or group '%v' which does not exists in this config", alias, target))
+		}
+	}
+	return nil
+}
+
+// Checks whether alias exists in configuration
+func (uc *UserConfig) DoesAliasExist(alias string) bool {
+	_, exists := uc.Aliases[alias]
+	return exists
+}
+
+// Checks whether group exists in configuration
+func (uc *UserConfig) DoesGroupExist(group string) bool {
+	_, exists := uc.Groups[group]
+	return exists
+}
+
+// SelectCommands expands a Selection into the commands it refers to, along
+// with the commands they depend on. Commands are returned once, in the order
+// they were first selected, with dependencies before their dependents.
+func (uc *UserConfig) SelectCommands(selection Selection) ([]*process.Command, error) {
+	var names []string
+
+	if selection.All {
+		names = append(names, utils.SortedKeys(uc.Commands)...)
+	}
+
+	for _, name := range selection.Names {
+		if target, aliased := uc.Aliases[name]; aliased {
+			name = target
+		}
+		if uc.DoesGroupExist(name) {
+			names = append(names, uc.Groups[name]...)
+			continue
+		}
+		if !uc.DoesCommandExist(name) {
+			return nil, errors.New(fmt.Sprintf("Command, group or alias '%v' does not exists in this config", name))
+		}
+		names = append(names, name)
+	}
+
+	for _, tag := range selection.Tags {
+		tagged := uc.commandsWithTag(tag)
+		if len(tagged) == 0 {
+			return nil, errors.New(fmt.Sprintf("No command is tagged with '%v' in this config", tag))
+		}
+		names = append(names, tagged...)
+	}
+
+	var commands []*process.Command
+	for _, name := range uc.withDependencies(names) {
+		command, err := uc.GetCommand(name)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+// commandsWithTag returns the sorted names of every command with the given tag
+func (uc *UserConfig) commandsWithTag(tag string) []string {
+	var names []string
+	for _, name := range utils.SortedKeys(uc.Commands) {
+		if slices.Contains(uc.Commands[name].Tags, tag) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -5,21 +5,105 @@ import (
 	"fmt"
 
 	"github.com/anjolaoluwaakindipe/dues/internal/process"
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
 )
 
 type UserConfig struct {
-	Commands map[string]*process.Command
+	// Path or URL of the JSON Schema editors should use for the file
+	Schema   string                      `json:"$schema,omitempty" yaml:"$schema,omitempty" toml:"$schema,omitempty"`
+	Commands map[string]*process.Command `json:"commands" yaml:"commands" toml:"commands"`
+	Groups   map[string][]string         `json:"groups" yaml:"groups" toml:"groups"`
+	Vars     map[string]string           `json:"vars" yaml:"vars" toml:"vars"`
+	// Alternative names of commands and groups, such as "fe" for "frontend"
+	Aliases map[string]string `json:"aliases" yaml:"aliases" toml:"aliases"`
+	// Config files whose commands, groups and vars are merged into this one
+	Extends []string `json:"extends" yaml:"extends" toml:"extends"`
+	// Config files whose commands and groups are added under the name of
+	// their directory
+	Include []string `json:"include" yaml:"include" toml:"include"`
+	// Named sets of command overrides, selected with --profile or DUES_PROFILE
+	Profiles map[string]Profile `json:"profiles" yaml:"profiles" toml:"profiles"`
+
+	path      string
+	files     []string
+	varScopes map[string]*varScope
+	// set once Process got to processing the commands
+	processed bool
 }
 
 // Takes the configuration given and uses it to help validate and process
-// the internal command
+// the internal command. Every invalid command is reported in the returned error
 func (uc *UserConfig) Process(configPath string) error {
-	for _, v := range uc.Commands {
-		if err := v.Process(configPath); err != nil {
-			return err
+	if err := uc.processGroups(); err != nil {
+		return err
+	}
+
+	if err := uc.processAliases(); err != nil {
+		return err
+	}
+
+	if err := uc.processDependencies(); err != nil {
+		return err
+	}
+
+	vars, err := resolveVars(uc.Vars, configPath)
+	if err != nil {
+		return err
+	}
+
+	uc.processed = true
+	var errs []error
+	for _, name := range utils.SortedKeys(uc.Commands) {
+		command := uc.Commands[name]
+		if command == nil {
+			errs = append(errs, errors.New(fmt.Sprintf("Command '%v' has no definition", name)))
+			continue
+		}
+		command.Name = name
+
+		commandVars := vars
+		if scope, scoped := uc.varScopes[name]; scoped {
+			commandVars, err = resolveVars(scope.vars, scope.configPath)
+			if err != nil {
+				errs = append(errs, withSource(command, configPath, err))
+				continue
+			}
+		}
+
+		commandConfigPath := configPath
+		if command.Source != "" {
+			commandConfigPath = command.Source
+		}
+		if err := command.Process(commandConfigPath, commandVars); err != nil {
+			errs = append(errs, withSource(command, configPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withSource adds the file command was defined in to err when it is not the
+// main config file
+func withSource(command *process.Command, configPath string, err error) error {
+	if err == nil || command.Source == "" || command.Source == configPath {
+		return err
+	}
+	return fmt.Errorf("%w (defined in %v)", err, command.Source)
+}
+
+// Validate checks that every processed command can actually be run. It is
+// meant to be called after Process and reports every problem found. Nothing
+// is checked when Process failed before getting to the commands
+func (uc *UserConfig) Validate() error {
+	if !uc.processed {
+		return nil
+	}
+	var errs []error
+	for _, name := range utils.SortedKeys(uc.Commands) {
+		if command := uc.Commands[name]; command != nil {
+			errs = append(errs, withSource(command, uc.path, command.Validate()))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // Checks whether command exists in configuration
@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/interpolate"
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// resolveVars expands the vars defined in the config file at configPath and
+// returns the lookup commands use to resolve ${VAR} references. Vars may
+// reference each other, the ${configDir} built-in and the environment, in that
+// order of precedence.
+func resolveVars(vars map[string]string, configPath string) (interpolate.Lookup, error) {
+	builtins := interpolate.Map(map[string]string{
+		"configDir": filepath.Dir(configPath),
+	})
+
+	resolved := make(map[string]string, len(vars))
+	resolving := make(map[string]bool)
+
+	var resolve func(name string) (string, bool, error)
+	resolve = func(name string) (string, bool, error) {
+		if value, done := resolved[name]; done {
+			return value, true, nil
+		}
+		raw, exists := vars[name]
+		if !exists {
+			return "", false, nil
+		}
+		if resolving[name] {
+			return "", false, errors.New(fmt.Sprintf("Var '%v' has a circular reference", name))
+		}
+		resolving[name] = true
+		defer delete(resolving, name)
+
+		var innerErr error
+		value, err := interpolate.Expand(raw, interpolate.Chain(builtins, func(inner string) (string, bool) {
+			value, exists, err := resolve(inner)
+			if err != nil && innerErr == nil {
+				innerErr = err
+			}
+			return value, exists
+		}, os.LookupEnv))
+		if innerErr != nil {
+			return "", false, innerErr
+		}
+		if err != nil {
+			return "", false, errors.New(fmt.Sprintf("Var '%v' has an invalid value: %v", name, err))
+		}
+
+		resolved[name] = value
+		return value, true, nil
+	}
+
+	for _, name := range utils.SortedKeys(vars) {
+		if !interpolate.IsName(name) {
+			return nil, errors.New(fmt.Sprintf("Var '%v' has an invalid name, names are made of letters, digits and underscores", name))
+		}
+		if _, _, err := resolve(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return interpolate.Chain(builtins, interpolate.Map(resolved), os.LookupEnv), nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveVars(t *testing.T) {
+	t.Setenv("DUES_TEST_HOST", "example.com")
+
+	lookup, err := resolveVars(map[string]string{
+		"port":   "8080",
+		"addr":   "${host}:${port}",
+		"host":   "${DUES_TEST_HOST}",
+		"data":   "${configDir}/data",
+		"escape": "$${port}",
+	}, "/project/dues.yaml")
+	if err != nil {
+		t.Fatalf("resolveVars returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"port", "8080"},
+		{"host", "example.com"},
+		{"addr", "example.com:8080"},
+		{"data", "/project/data"},
+		{"escape", "${port}"},
+		{"configDir", "/project"},
+		{"DUES_TEST_HOST", "example.com"},
+	}
+
+	for _, test := range tests {
+		got, exists := lookup(test.name)
+		if !exists || got != test.want {
+			t.Errorf("lookup(%q) = %q, %v, want %q", test.name, got, exists, test.want)
+		}
+	}
+}
+
+func TestResolveVarsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"self reference", map[string]string{"a": "${a}"}, "Var 'a' has a circular reference"},
+		{"circular reference", map[string]string{"a": "${b}", "b": "${a}"}, "has a circular reference"},
+		{"unresolved variable", map[string]string{"a": "${DUES_TEST_MISSING}"}, "Var 'a' has an invalid value: unresolved variable ${DUES_TEST_MISSING}"},
+		{"invalid name", map[string]string{"api-port": "8080"}, "Var 'api-port' has an invalid name"},
+		{"unterminated reference", map[string]string{"a": "${b"}, "Var 'a' has an invalid value: unterminated variable reference"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := resolveVars(test.vars, "/project/dues.yaml")
+			if err == nil {
+				t.Fatalf("resolveVars(%v) succeeded, want error containing %q", test.vars, test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("resolveVars(%v) error = %q, want it to contain %q", test.vars, err, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package debounce
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type DebounceSignal int
 
@@ -13,57 +16,115 @@ type Debouncer struct {
 	delay      time.Duration
 	signalChan chan DebounceSignal
 	started    bool
-	timer      *time.Timer
+	mutex      sync.Mutex
 }
 
 func NewDebouncer() *Debouncer {
 	return &Debouncer{
-		signalChan: make(chan DebounceSignal),
+		signalChan: make(chan DebounceSignal, 1),
 		started:    false,
 	}
 }
 
 // Start invokess the debouncer callback after a specific delay.
 // If the reset method is called before the callback is invoked then
-// the delay will be reset. If the Cancel Method is called then the delay will 
-// be stopped and the callback will not be invoked
+// the delay will be reset. If the Cancel Method is called then the delay will
+// be stopped and the callback will not be invoked. The debouncer counts as
+// stopped while the callback runs, so a long running callback does not block
+// Reset or Cancel
 func (self *Debouncer) Start(delay time.Duration, callback func()) {
-	self.timer = time.NewTimer(delay)
-  defer func(){
-    self.started = false
-  }()
+	self.mutex.Lock()
+	self.started = true
+	self.delay = delay
+	self.mutex.Unlock()
+
+	self.wait(callback)
+}
+
+// StartAsync behaves like Start but waits for the delay in a new goroutine. The
+// debouncer is marked as started before StartAsync returns, and nothing happens
+// if it was already started, in which case false is returned
+func (self *Debouncer) StartAsync(delay time.Duration, callback func()) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.started {
+		return false
+	}
+	self.started = true
+	self.delay = delay
+
+	go self.wait(callback)
+	return true
+}
+
+// wait blocks until the delay passes without a reset and invokes callback, or
+// until the debouncer is cancelled
+func (self *Debouncer) wait(callback func()) {
+	self.mutex.Lock()
+	timer := time.NewTimer(self.delay)
+	self.mutex.Unlock()
+
 	for {
-		self.started = true
 		select {
 		case sig := <-self.signalChan:
-			if sig == reset {
-				self.timer.Reset(delay)
-			} else if sig == cancel {
-				self.timer.Stop()
+			timer.Stop()
+			if sig == cancel {
 				return
 			}
-		case <-self.timer.C:
+			self.mutex.Lock()
+			timer = time.NewTimer(self.delay)
+			self.mutex.Unlock()
+		case <-timer.C:
+			self.mutex.Lock()
+			if len(self.signalChan) > 0 {
+				// a reset or cancel raced the timer, handle it first
+				self.mutex.Unlock()
+				continue
+			}
+			self.started = false
+			self.mutex.Unlock()
+
 			callback()
-      return 
+			return
 		}
 	}
 }
 
+// Reset restarts the delay of a started debouncer, using delay as the new
+// delay when it is not nil. False is returned when the debouncer is not started
 func (self *Debouncer) Reset(delay *time.Duration) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
 	if !self.started {
 		return false
 	}
-  if delay != nil {
-    self.timer = time.NewTimer(*delay)
-  }
-	self.signalChan <- reset
+	if delay != nil {
+		self.delay = *delay
+	}
+	select {
+	case self.signalChan <- reset:
+	default:
+		// a reset is already pending
+	}
 	return true
 }
 
+// Cancel stops a started debouncer without invoking its callback. False is
+// returned when the debouncer is not started
 func (self *Debouncer) Cancel() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
 	if !self.started {
 		return false
 	}
+	self.started = false
+	select {
+	case <-self.signalChan:
+	default:
+	}
 	self.signalChan <- cancel
 	return true
 }
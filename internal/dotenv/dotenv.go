@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadFile parses the dotenv file at path
+func ReadFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	env, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v:%w", path, err)
+	}
+	return env, nil
+}
+
+// Parse reads KEY=VALUE pairs in the dotenv format. Blank lines and lines
+// starting with # are skipped and a leading "export " is allowed. Values can be
+// unquoted, in which case a " #" starts a comment, single quoted, which is
+// taken literally, or double quoted, which supports \n, \t, \" and \\ escapes
+// and can span multiple lines.
+func Parse(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%d: expected KEY=VALUE but got '%v'", lineNumber, line)
+		}
+
+		value = strings.TrimSpace(value)
+		startLine := lineNumber
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// double quoted values may continue over several lines
+			for !hasClosingQuote(value[1:], '"') {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("%d: unterminated double quoted value for '%v'", startLine, key)
+				}
+				lineNumber++
+				value += "\n" + scanner.Text()
+			}
+			value = unescape(value[1 : closingQuote(value[1:], '"')+1])
+		case strings.HasPrefix(value, "'"):
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unterminated single quoted value for '%v'", startLine, key)
+			}
+			value = value[1 : end+1]
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = value[:comment]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		env[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// closingQuote returns the index of the first unescaped quote in s or -1
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func hasClosingQuote(s string, quote byte) bool {
+	return closingQuote(s, quote) >= 0
+}
+
+var escapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescape(s string) string {
+	return escapes.Replace(s)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package dotenv
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{"plain", "FOO=bar\nBAZ=qux", map[string]string{"FOO": "bar", "BAZ": "qux"}},
+		{"blank lines and comments", "\n# a comment\n  # indented comment\nFOO=bar\n\n", map[string]string{"FOO": "bar"}},
+		{"export prefix", "export FOO=bar", map[string]string{"FOO": "bar"}},
+		{"surrounding whitespace", "  FOO = bar  ", map[string]string{"FOO": "bar"}},
+		{"empty value", "FOO=", map[string]string{"FOO": ""}},
+		{"value containing equals", "URL=postgres://u:p@h/db?sslmode=disable", map[string]string{"URL": "postgres://u:p@h/db?sslmode=disable"}},
+		{"unquoted trailing comment", "FOO=bar # comment", map[string]string{"FOO": "bar"}},
+		{"hash without space is kept", "COLOR=#fff", map[string]string{"COLOR": "#fff"}},
+		{"single quotes are literal", `FOO='a \n $B # c'`, map[string]string{"FOO": `a \n $B # c`}},
+		{"double quotes unescape", `FOO="a\tb\nc \"d\" \\e"`, map[string]string{"FOO": "a\tb\nc \"d\" \\e"}},
+		{"double quotes keep hash", `FOO="a # b" # comment`, map[string]string{"FOO": "a # b"}},
+		{"multi-line double quotes", "KEY=\"line one\nline two\"\nNEXT=1", map[string]string{"KEY": "line one\nline two", "NEXT": "1"}},
+		{"later keys win", "FOO=1\nFOO=2", map[string]string{"FOO": "2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", test.input, err)
+			}
+			if !maps.Equal(got, test.want) {
+				t.Errorf("Parse(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing equals", "FOO", "1: expected KEY=VALUE"},
+		{"empty key", "=bar", "1: expected KEY=VALUE"},
+		{"key with space", "FOO BAR=1", "1: expected KEY=VALUE"},
+		{"unterminated double quote", "A=1\nFOO=\"bar\nbaz", "2: unterminated double quoted value for 'FOO'"},
+		{"unterminated single quote", "FOO='bar", "1: unterminated single quoted value for 'FOO'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input))
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error containing %q", test.input, test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", test.input, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+)
+
+// FocusPrefix starts a line that switches which command receives input,
+// such as "@api". A line starting with two of them is forwarded without the
+// first one
+const FocusPrefix = "@"
+
+// Router forwards the lines read from the terminal to the command in focus.
+// The terminal is left in its normal line mode, so Ctrl-C still reaches dues
+// as a signal instead of being forwarded
+type Router struct {
+	in    io.Reader
+	mutex sync.Mutex
+	// commands accepting input, in the order they registered
+	names []string
+	// stdin of the running process of every command, if it is running
+	stdins map[string]io.WriteCloser
+	focus  string
+	closed bool
+	once   sync.Once
+}
+
+// NewRouter creates a router forwarding what is read from in, usually the
+// terminal
+func NewRouter(in io.Reader) *Router {
+	return &Router{in: in, stdins: make(map[string]io.WriteCloser)}
+}
+
+// Register adds a command accepting input. The first command registered gets
+// the focus, and reading starts with the first registration
+func (r *Router) Register(name string) {
+	r.mutex.Lock()
+	if !slices.Contains(r.names, name) {
+		r.names = append(r.names, name)
+	}
+	if r.focus == "" {
+		r.focus = name
+		log.Logger.Info(fmt.Sprintf("Terminal input goes to %v, type %vname to send it to another command", name, FocusPrefix))
+	}
+	r.mutex.Unlock()
+
+	r.once.Do(func() {
+		go r.forward(r.in)
+	})
+}
+
+// Unregister removes a command, moving the focus to the first remaining one
+// if it had it
+func (r *Router) Unregister(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if index := slices.Index(r.names, name); index >= 0 {
+		r.names = slices.Delete(r.names, index, index+1)
+	}
+	if r.focus == name {
+		r.focus = ""
+		if len(r.names) > 0 {
+			r.setFocus(r.names[0])
+		}
+	}
+}
+
+// Attach connects stdin to the running process of the named command. It is
+// closed by the router once it reaches the end of its input
+func (r *Router) Attach(name string, stdin io.WriteCloser) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		stdin.Close()
+		return
+	}
+	r.stdins[name] = stdin
+}
+
+// Detach disconnects stdin once the process it belongs to exited
+func (r *Router) Detach(name string, stdin io.WriteCloser) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stdins[name] == stdin {
+		delete(r.stdins, name)
+	}
+}
+
+// forward reads in line by line until its end, handing every line to the
+// command in focus
+func (r *Router) forward(in io.Reader) {
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			r.handle(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// the end of the input is passed on to every process
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	for name, stdin := range r.stdins {
+		stdin.Close()
+		delete(r.stdins, name)
+	}
+}
+
+// handle switches the focus or forwards line to the command in focus
+func (r *Router) handle(line string) {
+	if strings.HasPrefix(line, FocusPrefix+FocusPrefix) {
+		line = strings.TrimPrefix(line, FocusPrefix)
+	} else if strings.HasPrefix(line, FocusPrefix) {
+		r.switchFocus(strings.TrimSpace(strings.TrimPrefix(line, FocusPrefix)))
+		return
+	}
+
+	// the line is written without holding the mutex, a process that does not
+	// read its input would otherwise block Detach once it exits
+	r.mutex.Lock()
+	focus := r.focus
+	stdin, running := r.stdins[focus]
+	r.mutex.Unlock()
+
+	if !running {
+		log.Logger.Error(fmt.Sprintf("Command %v is not running, its input was dropped", focus))
+		return
+	}
+	if _, err := io.WriteString(stdin, line); err != nil {
+		log.Logger.Error(fmt.Sprintf("Could not forward input to command %v: %v", focus, err))
+	}
+}
+
+// switchFocus moves the focus to the named command if it accepts input
+func (r *Router) switchFocus(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !slices.Contains(r.names, name) {
+		log.Logger.Error(fmt.Sprintf("Command '%v' does not accept input, choose one of %v", name, strings.Join(r.names, ", ")))
+		return
+	}
+	r.setFocus(name)
+}
+
+// setFocus moves the focus to name. The mutex must be held by the caller
+func (r *Router) setFocus(name string) {
+	r.focus = name
+	log.Logger.Info(fmt.Sprintf("Terminal input now goes to %v", name))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package interpolate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lookup resolves the value of a variable. The boolean is false when the
+// variable does not exist.
+type Lookup func(name string) (string, bool)
+
+// UnresolvedError is returned when a string references variables that could
+// not be resolved
+type UnresolvedError struct {
+	Names []string
+}
+
+func (ue *UnresolvedError) Error() string {
+	references := make([]string, len(ue.Names))
+	for i, name := range ue.Names {
+		references[i] = "${" + name + "}"
+	}
+	return fmt.Sprintf("unresolved variable %v, write $${ for a literal ${", strings.Join(references, ", "))
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsName reports whether name can be referenced as ${name}
+func IsName(name string) bool {
+	return identifier.MatchString(name)
+}
+
+// Expand replaces every ${NAME} in s with the value returned by lookup, where
+// NAME is made of letters, digits and underscores and does not start with a
+// digit. Other forms such as the shell's ${VAR:-default} are left alone, and a
+// literal "${" can be written as "$${". Any reference lookup cannot resolve is
+// reported through an *UnresolvedError.
+func Expand(s string, lookup Lookup) (string, error) {
+	var result strings.Builder
+	var unresolved []string
+
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			result.WriteString(s)
+			break
+		}
+
+		if start > 0 && s[start-1] == '$' {
+			result.WriteString(s[:start])
+			result.WriteString("{")
+			s = s[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in '%v'", s)
+		}
+
+		name := strings.TrimSpace(s[start+2 : start+end])
+		if !IsName(name) {
+			// not a reference of dues, references inside of it are still
+			// expanded
+			result.WriteString(s[:start+2])
+			s = s[start+2:]
+			continue
+		}
+		result.WriteString(s[:start])
+		if value, exists := lookup(name); exists {
+			result.WriteString(value)
+		} else {
+			unresolved = append(unresolved, name)
+		}
+		s = s[start+end+1:]
+	}
+
+	if len(unresolved) > 0 {
+		return "", &UnresolvedError{Names: unresolved}
+	}
+	return result.String(), nil
+}
+
+// Chain returns a Lookup that tries each lookup in order
+func Chain(lookups ...Lookup) Lookup {
+	return func(name string) (string, bool) {
+		for _, lookup := range lookups {
+			if lookup == nil {
+				continue
+			}
+			if value, exists := lookup(name); exists {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// Map returns a Lookup backed by values
+func Map(values map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		value, exists := values[name]
+		return value, exists
+	}
+}
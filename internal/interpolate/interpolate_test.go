@@ -0,0 +1,108 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package interpolate
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	lookup := Map(map[string]string{"NAME": "api", "PORT": "8080", "EMPTY": ""})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"no references", "go run .", "go run ."},
+		{"single reference", "${NAME}", "api"},
+		{"embedded references", "serve --name ${NAME} --port=${PORT}", "serve --name api --port=8080"},
+		{"adjacent references", "${NAME}${PORT}", "api8080"},
+		{"spaces inside braces", "${ NAME }", "api"},
+		{"empty value", "a${EMPTY}b", "ab"},
+		{"escaped reference", "$${NAME}", "${NAME}"},
+		{"escaped next to reference", "$${NAME}=${NAME}", "${NAME}=api"},
+		{"plain dollar is kept", "echo $HOME $1", "echo $HOME $1"},
+		{"shell default is kept", "echo ${PORT:-3000}", "echo ${PORT:-3000}"},
+		{"shell length is kept", "echo ${#NAME} ${}", "echo ${#NAME} ${}"},
+		{"reference inside shell default", "echo ${PORT:-${PORT}}", "echo ${PORT:-8080}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Expand(test.input, lookup)
+			if err != nil {
+				t.Fatalf("Expand(%q) returned error: %v", test.input, err)
+			}
+			if got != test.want {
+				t.Errorf("Expand(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandUnresolved(t *testing.T) {
+	lookup := Map(map[string]string{"NAME": "api"})
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"unknown variable", "${MISSING}", []string{"MISSING"}},
+		{"every unknown variable", "${A} ${NAME} ${B}", []string{"A", "B"}},
+		{"unknown inside shell default", "${A:-${MISSING}}", []string{"MISSING"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Expand(test.input, lookup)
+			var unresolved *UnresolvedError
+			if !errors.As(err, &unresolved) {
+				t.Fatalf("Expand(%q) error = %v, want an *UnresolvedError", test.input, err)
+			}
+			if !slices.Equal(unresolved.Names, test.want) {
+				t.Errorf("Expand(%q) unresolved %q, want %q", test.input, unresolved.Names, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandUnterminated(t *testing.T) {
+	_, err := Expand("echo ${NAME", Map(nil))
+	if err == nil || !strings.Contains(err.Error(), "unterminated variable reference") {
+		t.Errorf("Expand of an unterminated reference returned %v, want an unterminated variable reference error", err)
+	}
+}
+
+func TestUnresolvedErrorMessage(t *testing.T) {
+	err := &UnresolvedError{Names: []string{"A", "B"}}
+	if got, want := err.Error(), "unresolved variable ${A}, ${B}, write $${ for a literal ${"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestChain(t *testing.T) {
+	lookup := Chain(nil, Map(map[string]string{"A": "first"}), Map(map[string]string{"A": "second", "B": "b"}))
+
+	tests := []struct {
+		name   string
+		want   string
+		exists bool
+	}{
+		{"A", "first", true},
+		{"B", "b", true},
+		{"C", "", false},
+	}
+
+	for _, test := range tests {
+		got, exists := lookup(test.name)
+		if got != test.want || exists != test.exists {
+			t.Errorf("lookup(%q) = %q, %v, want %q, %v", test.name, got, exists, test.want, test.exists)
+		}
+	}
+}
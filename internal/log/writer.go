@@ -41,11 +41,13 @@ func (dw *DuesWriter) Write(p []byte) (int, error) {
 	n, err := dw.writer.Write([]byte(data))
 
 	if err != nil {
-		return n, err
+		return 0, err
 	}
 
-	if n != len(p) {
-		return n, io.ErrShortWrite
+	// n counts the prefix as well, so it is compared against everything that
+	// was written rather than p
+	if n != len(data) {
+		return 0, io.ErrShortWrite
 	}
 
 	return len(p), nil
@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// shortWriter writes at most limit bytes of every call
+type shortWriter struct {
+	limit int
+}
+
+func (sw shortWriter) Write(p []byte) (int, error) {
+	return min(len(p), sw.limit), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 3, errors.New("closed")
+}
+
+func TestDuesWriterWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		writer  io.Writer
+		want    int
+		wantErr error
+	}{
+		{"prefixed line", &bytes.Buffer{}, 5, nil},
+		{"short write", shortWriter{limit: 10}, 0, io.ErrShortWrite},
+		{"failed write", failingWriter{}, 0, errors.New("closed")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dw := NewDuesWriter(test.writer, "INFO", "api")
+			n, err := dw.Write([]byte("hello"))
+			if n != test.want {
+				t.Errorf("Write returned %d bytes, want %d", n, test.want)
+			}
+			if (err == nil) != (test.wantErr == nil) || (err != nil && err.Error() != test.wantErr.Error()) {
+				t.Errorf("Write returned error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestDuesWriterPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	dw := &DuesWriter{writer: &buf, severity: "ERROR", time: "now", command: "api"}
+	if _, err := dw.Write([]byte("boom\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if got, want := buf.String(), "api now ERROR boom\n"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package pattern
 
 import (
+	"errors"
 	"regexp"
 	"strings"
 )
@@ -32,3 +33,13 @@ func wildCardMatch(str string, pattern string) bool {
 	result, _ := regexp.MatchString(wildCardBuilder(pattern), str)
 	return result
 }
+
+// Validate checks that pattern is a usable wildcard pattern. An empty pattern
+// is rejected because it would match every path
+func Validate(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("empty pattern matches every path")
+	}
+	_, err := regexp.Compile(wildCardBuilder(pattern))
+	return err
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// maxLineLength bounds how much of a line without a line break is kept
+const maxLineLength = 64 * 1024
+
+// LogMatcher watches the output of a process for a line matching a regular
+// expression
+type LogMatcher struct {
+	pattern *regexp.Regexp
+	matched chan struct{}
+	once    sync.Once
+}
+
+func NewLogMatcher(pattern string) (*LogMatcher, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &LogMatcher{pattern: compiled, matched: make(chan struct{})}, nil
+}
+
+// Writer returns a writer for one output stream of the process. Every stream
+// needs its own writer so that their lines are not mixed up
+func (lm *LogMatcher) Writer() *LineWriter {
+	return &LineWriter{matcher: lm}
+}
+
+// Check succeeds once a matching line was written
+func (lm *LogMatcher) Check(ctx context.Context) error {
+	select {
+	case <-lm.matched:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("no line matched /%v/ yet", lm.pattern))
+	}
+}
+
+func (lm *LogMatcher) match(line []byte) {
+	if lm.pattern.Match(line) {
+		lm.once.Do(func() { close(lm.matched) })
+	}
+}
+
+// LineWriter splits what is written to it into lines for a LogMatcher
+type LineWriter struct {
+	matcher *LogMatcher
+	mutex   sync.Mutex
+	partial []byte
+}
+
+func (lw *LineWriter) Write(p []byte) (int, error) {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+
+	lw.partial = append(lw.partial, p...)
+	for {
+		end := bytes.IndexByte(lw.partial, '\n')
+		if end < 0 {
+			break
+		}
+		lw.matcher.match(bytes.TrimSuffix(lw.partial[:end], []byte("\r")))
+		lw.partial = lw.partial[end+1:]
+	}
+	if len(lw.partial) > maxLineLength {
+		lw.matcher.match(lw.partial)
+		lw.partial = nil
+	}
+	return len(p), nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Check reports whether the probed service is up, returning why not otherwise
+type Check func(ctx context.Context) error
+
+// TCP checks that a connection to address can be opened
+func TCP(address string) Check {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTP checks that a GET request to url is answered with a 2xx status
+func HTTP(url string) Check {
+	return func(ctx context.Context) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return errors.New(fmt.Sprintf("%v answered with status %v", url, response.Status))
+		}
+		return nil
+	}
+}
+
+// File checks that path exists
+func File(path string) Check {
+	return func(ctx context.Context) error {
+		_, err := os.Stat(path)
+		return err
+	}
+}
+
+// Poll runs check every interval until it succeeds or ctx is done. The error
+// of the last attempt that was not cut short by ctx is returned when ctx ends
+// first
+func Poll(ctx context.Context, interval time.Duration, check Check) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last error
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == nil || last == nil {
+			last = err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return last
+		}
+	}
+}
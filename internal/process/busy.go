@@ -0,0 +1,48 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OnBusy decides what a file change does to a command whose main command is
+// still running
+type OnBusy string
+
+const (
+	// Stop the running instance and start a new one
+	OnBusyRestart OnBusy = "restart"
+	// Let the running instance finish and run the command once more afterwards
+	OnBusyQueue OnBusy = "queue"
+	// Ignore the change
+	OnBusyDoNothing OnBusy = "do-nothing"
+	// Send ReloadSignal to the running instance
+	OnBusySignal OnBusy = "signal"
+)
+
+var onBusyOptions = []OnBusy{OnBusyRestart, OnBusyQueue, OnBusyDoNothing, OnBusySignal}
+
+func (ob OnBusy) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": onBusyOptions,
+	}
+}
+
+// Validates the onBusy field and fills in its default
+func (c *Command) processOnBusy() error {
+	c.OnBusy = OnBusy(strings.ToLower(strings.TrimSpace(string(c.OnBusy))))
+	if c.OnBusy == "" {
+		c.OnBusy = OnBusyRestart
+	}
+
+	known := false
+	for _, option := range onBusyOptions {
+		known = known || c.OnBusy == option
+	}
+	if !known {
+		return errors.New(fmt.Sprintf("Command '%v' has an unknown onBusy field '%v', expected restart, queue, do-nothing or signal", c.Name, c.OnBusy))
+	}
+	return nil
+}
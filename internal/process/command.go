@@ -1,33 +1,123 @@
 package process
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/anjolaoluwaakindipe/dues/internal/interpolate"
 	"github.com/anjolaoluwaakindipe/dues/internal/log"
 )
 
 type Command struct {
-	Command     string
-	PreCommand  string
-	PostCommand string
-	Cwd         string
-	Name        string
-	Ignore      []string
-	Include     []string
-	Color       log.StringColor
-}
-
-// Validates the command structure
-func (c *Command) Process(configPath string) error {
+	Command     CommandLine `json:"command" yaml:"command" toml:"command"`
+	PreCommand  CommandLine `json:"preCommand" yaml:"preCommand" toml:"preCommand"`
+	PostCommand CommandLine `json:"postCommand" yaml:"postCommand" toml:"postCommand"`
+	// Ordered steps run instead of Command. Every step but the last one has to
+	// succeed before the last, long running, step replaces the running instance
+	Steps []CommandLine `json:"steps" yaml:"steps" toml:"steps"`
+	// Shell that string command lines are run with, such as "bash -lc".
+	// Defaults to "sh -c"
+	Shell   string   `json:"shell" yaml:"shell" toml:"shell"`
+	Cwd     string   `json:"cwd" yaml:"cwd" toml:"cwd"`
+	Name    string   `json:"-" yaml:"-" toml:"-"`
+	Ignore  []string `json:"ignore" yaml:"ignore" toml:"ignore"`
+	Include []string `json:"include" yaml:"include" toml:"include"`
+	Tags    []string `json:"tags" yaml:"tags" toml:"tags"`
+	// Commands that have to be started before this one. They are started along
+	// with it and stopped after it
+	DependsOn []string `json:"dependsOn" yaml:"dependsOn" toml:"dependsOn"`
+	// Restart the command whenever one of the commands it depends on restarts
+	RestartWithDependencies bool `json:"restartWithDependencies" yaml:"restartWithDependencies" toml:"restartWithDependencies"`
+	// Probe telling when the started command is ready. Without one the command
+	// is ready as soon as it starts
+	Ready *ReadyProbe `json:"ready" yaml:"ready" toml:"ready"`
+	// Check restarting the command once it stops answering
+	Healthcheck *Healthcheck `json:"healthcheck" yaml:"healthcheck" toml:"healthcheck"`
+	// Forward terminal input to the command. When several commands accept
+	// input, a line such as "@name" moves the input to another one
+	Stdin bool `json:"stdin" yaml:"stdin" toml:"stdin"`
+	// Run the command in a pseudo-terminal so that it keeps its colors and
+	// progress output. Its stdout and stderr are merged. Linux only
+	TTY bool `json:"tty" yaml:"tty" toml:"tty"`
+	// Environment variables set for every process of the command. They take
+	// precedence over the variables read from EnvFile
+	Env map[string]string `json:"env" yaml:"env" toml:"env"`
+	// Dotenv files loaded in order, relative paths are resolved against Cwd
+	EnvFile []string `json:"envFile" yaml:"envFile" toml:"envFile"`
+	// Start from an empty environment instead of the one dues runs in. Only
+	// the variables named in PassEnv are inherited
+	CleanEnv bool     `json:"cleanEnv" yaml:"cleanEnv" toml:"cleanEnv"`
+	PassEnv  []string `json:"passEnv" yaml:"passEnv" toml:"passEnv"`
+	// How long file changes have to settle before the command is restarted
+	Debounce Duration `json:"debounce" yaml:"debounce" toml:"debounce"`
+	// Signal sent to the process group of the command when it is stopped.
+	// Defaults to SIGTERM
+	StopSignal string `json:"stopSignal" yaml:"stopSignal" toml:"stopSignal"`
+	// How long the command has to exit after StopSignal before it is killed.
+	// Defaults to 5s
+	StopTimeout Duration `json:"stopTimeout" yaml:"stopTimeout" toml:"stopTimeout"`
+	// What a file change does while the command is still running: restart,
+	// queue, do-nothing or signal. Defaults to restart
+	OnBusy OnBusy `json:"onBusy" yaml:"onBusy" toml:"onBusy"`
+	// Signal sent to the process group of the running command instead of
+	// restarting it when files change, for commands that reload on a signal.
+	// It is also what OnBusy signal sends, in which case it defaults to SIGHUP
+	ReloadSignal string `json:"reloadSignal" yaml:"reloadSignal" toml:"reloadSignal"`
+	// Patterns of the files whose changes send ReloadSignal, such as *.html.
+	// They are matched like the patterns of On, see Rule. Changes to other
+	// files restart the command. Every change sends it when empty
+	ReloadOn []string `json:"reloadOn" yaml:"reloadOn" toml:"reloadOn"`
+	// Rules mapping patterns of changed files to actions, such as running a
+	// code generator without restarting the command. They are evaluated in
+	// order and the first one matching a file handles it
+	On []Rule `json:"on" yaml:"on" toml:"on"`
+	// Whether the command is started again once it exits by itself: never,
+	// on-failure or always. Defaults to never
+	Restart RestartPolicy `json:"restart" yaml:"restart" toml:"restart"`
+	// Delay before the first restart, doubled on every consecutive restart up
+	// to RestartMaxDelay. Defaults to 1s and 30s
+	RestartDelay    Duration `json:"restartDelay" yaml:"restartDelay" toml:"restartDelay"`
+	RestartMaxDelay Duration `json:"restartMaxDelay" yaml:"restartMaxDelay" toml:"restartMaxDelay"`
+	// How many consecutive restarts are attempted before giving up. Zero
+	// means no limit
+	MaxRestarts int `json:"maxRestarts" yaml:"maxRestarts" toml:"maxRestarts"`
+	// Restarting stops once the command crashed CrashLoopCount times within
+	// CrashLoopWindow. Defaults to 5 crashes within 1m
+	CrashLoopCount  int             `json:"crashLoopCount" yaml:"crashLoopCount" toml:"crashLoopCount"`
+	CrashLoopWindow Duration        `json:"crashLoopWindow" yaml:"crashLoopWindow" toml:"crashLoopWindow"`
+	Color           log.StringColor `json:"-" yaml:"-" toml:"-"`
+	// Config file the command was defined in
+	Source string `json:"-" yaml:"-" toml:"-"`
+
+	exitMutex sync.Mutex
+	lastExit  *Exit
+	// pid of the running instance of the main command, zero when none is
+	// running
+	pidMutex sync.Mutex
+	pid      int
+}
+
+// Validates the command structure. Variable references in its fields are
+// resolved with vars and the ${configDir}, ${name} and ${cwd} built-ins.
+func (c *Command) Process(configPath string, vars interpolate.Lookup) error {
   c.Color = log.GetRandomStringColor()
 
+	if err := c.interpolate(configPath, vars); err != nil {
+		return err
+	}
+
 	if err := c.processCommand(); err != nil {
 		return err
 	}
@@ -40,116 +130,279 @@ func (c *Command) Process(configPath string) error {
 		return err
 	}
 
-	if err := c.processCwd(configPath); err != nil {
+	if err := c.processEnv(); err != nil {
 		return err
 	}
 
+	if err := c.processStopSignal(); err != nil {
+		return err
+	}
+
+	if err := c.processOnBusy(); err != nil {
+		return err
+	}
+
+	if err := c.processReload(); err != nil {
+		return err
+	}
+
+	if err := c.processRules(); err != nil {
+		return err
+	}
+
+	if err := c.processRestart(); err != nil {
+		return err
+	}
+
+	if err := c.processReady(); err != nil {
+		return err
+	}
+
+	if err := c.processHealthcheck(); err != nil {
+		return err
+	}
+
+	if c.TTY && runtime.GOOS != "linux" {
+		return errors.New(fmt.Sprintf("Command '%v' sets tty, which is only supported on Linux", c.Name))
+	}
+
 	return nil
 }
 
 // Validates the command field
 func (c *Command) processCommand() error {
-	c.Command = strings.TrimSpace(c.Command)
-	if len(c.Command) == 0 {
+	c.Command = c.Command.trimmed()
+	if len(c.Steps) > 0 {
+		return c.processSteps()
+	}
+	if c.Command.IsEmpty() {
 		return errors.New(fmt.Sprintf("Command '%v' has an empty command field", c.Name))
 	}
 	return nil
 }
 
-// Validates whether the cwd exists. If the Cwd field is a valid absolute path
-// then no error is returned. If not it is assumed that the Cwd path is a relative path
-// with respective to the configPath. Thus the Cwd field with be checked to validate that
-// it is a valid relative path
+// Resolves the cwd field. If the Cwd field is an absolute path it is used as is.
+// If not it is assumed that the Cwd path is a relative path with respect to the
+// directory of the configPath, and an empty Cwd is the config directory itself.
 func (c *Command) processCwd(configPath string) error {
 	c.Cwd = strings.TrimSpace(c.Cwd)
 
-	if !filepath.IsAbs(c.Cwd) {
+	if filepath.IsAbs(c.Cwd) {
+		c.Cwd = filepath.Clean(c.Cwd)
 		return nil
 	}
 
 	configDirPath := filepath.Dir(configPath)
-	cwd, err := filepath.Abs((filepath.Join(configDirPath, c.Cwd)))
-
-	c.Cwd = cwd
+	cwd, err := filepath.Abs(filepath.Join(configDirPath, c.Cwd))
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("Could not find path specified in command '%v' cwd's field.", c.Name))
+		return errors.New(fmt.Sprintf("Could not resolve path specified in command '%v' cwd's field.", c.Name))
 	}
 
+	c.Cwd = cwd
+
 	return nil
 }
 
 // Validates pre command
 func (c *Command) processPreCommand() error {
-	c.PreCommand = strings.TrimSpace(c.PreCommand)
+	c.PreCommand = c.PreCommand.trimmed()
 	return nil
 }
 
 // Validates post command
 func (c *Command) processPostCommand() error {
-	c.PostCommand = strings.TrimSpace(c.PostCommand)
+	c.PostCommand = c.PostCommand.trimmed()
 	return nil
 }
 
-// Converts comand string to slice, delimited by whitespaces
+// Converts comand to the argv it is executed with
 func (c *Command) commandSlice() []string {
-	commandAsSlice := strings.Fields(c.Command)
-	return commandAsSlice
+	return c.argv(c.mainStep())
 }
 
-// Converts pre comand string to slice, delimited by whitespaces
+// Converts pre comand to the argv it is executed with
 func (c *Command) preCommandSlice() []string {
-	commandAsSlice := strings.Fields(c.PreCommand)
-	return commandAsSlice
+	return c.argv(c.PreCommand)
 }
 
-// Converts post command string to slice, delimited by whitespaces
+// Converts post command to the argv it is executed with
 func (c *Command) postCommandSlice() []string {
-	commandAsSlice := strings.Fields(c.PostCommand)
-	return commandAsSlice
+	return c.argv(c.PostCommand)
+}
+
+// argv returns the arguments commandLine is executed with, running scripts
+// through the command's shell
+func (c *Command) argv(commandLine CommandLine) []string {
+	if commandLine.IsEmpty() {
+		return nil
+	}
+	return commandLine.Argv(c.Shell)
 }
 
 // Launches pre command
-func (c *Command) LaunchPreCommand(ctx context.Context) error {
+func (c *Command) LaunchPreCommand(ctx context.Context) (Exit, error) {
 	pcs := c.preCommandSlice()
 	return c.runCmd(pcs, ctx)
 }
 
-// Launches command
-func (c *Command) LaunchCommand(ctx context.Context) error {
-	cs := c.commandSlice()
-	return c.runCmd(cs, ctx)
+// Streams are connected to a run of a command in addition to the log output
+type Streams struct {
+	// Input of the process. Only used for commands with Stdin set
+	Stdin io.Reader
+	// Writers receiving a copy of the output of the process
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Launches command with streams connected to it. trigger is handed to the run
+// and started, if not nil, is called once the process started
+func (c *Command) LaunchCommand(ctx context.Context, streams Streams, trigger Trigger, started func()) (Exit, error) {
+	cs := c.argv(trigger.expand(c.mainStep()))
+	track := func(pid int) {
+		c.setPid(pid)
+		if pid != 0 && started != nil {
+			started()
+		}
+	}
+	return c.runCmdWith(cs, ctx, runOptions{streams: streams, env: trigger.environ(), track: track})
+}
+
+// setPid records the pid of the running instance of the main command
+func (c *Command) setPid(pid int) {
+	c.pidMutex.Lock()
+	defer c.pidMutex.Unlock()
+
+	c.pid = pid
+}
+
+// SignalCommand sends the signal called name to the process group of the
+// running instance of the main command
+func (c *Command) SignalCommand(name string) error {
+	signal, err := ParseSignal(name)
+	if err != nil {
+		return err
+	}
+
+	c.pidMutex.Lock()
+	defer c.pidMutex.Unlock()
+
+	if c.pid == 0 {
+		return errors.New(fmt.Sprintf("Command '%v' is not running", c.Name))
+	}
+	return signalGroup(c.pid, signal)
 }
 
 // Launches post command
-func (c *Command) LaunchPostCommand(ctx context.Context) error {
+func (c *Command) LaunchPostCommand(ctx context.Context) (Exit, error) {
 	pcs := c.postCommandSlice()
 	return c.runCmd(pcs, ctx)
 }
 
 // Runs a specific command. This is a blocking operation until said command finishes execution,
-// thus run in separate goroutine for if concurrency is needed
-func (c *Command) runCmd(command []string, ctx context.Context) error {
+// thus run in separate goroutine for if concurrency is needed. The command runs in its own
+// process group, which is stopped as a whole once ctx is cancelled. How the run ended is
+// returned and recorded as the last exit of the command
+func (c *Command) runCmd(command []string, ctx context.Context) (Exit, error) {
+	return c.runCmdWith(command, ctx, runOptions{})
+}
+
+// runOptions are the extras of a single run of a command
+type runOptions struct {
+	streams Streams
+	// variables added to the environment of the command
+	env []string
+	// called with the pid of the process once it started and with zero once
+	// it exited
+	track func(pid int)
+}
+
+// runCmdWith runs a command like runCmd with options applied to the run
+func (c *Command) runCmdWith(command []string, ctx context.Context, options runOptions) (Exit, error) {
 	if len(command) == 0 {
-		return errors.New("length of command string slice is zero")
+		return Exit{}, errors.New("length of command string slice is zero")
 	}
-	var cmd *exec.Cmd
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = c.Cwd
 
-	if len(command) == 1 {
-		cmd = exec.CommandContext(ctx, command[0])
-	} else {
-		cmd = exec.CommandContext(ctx, command[0], command[1:]...)
+	env, err := c.Environ()
+	if err != nil {
+		return Exit{}, err
 	}
-	cmd.Dir = c.Cwd
+	cmd.Env = append(env, options.env...)
+
 	cmd.Stderr = log.NewDuesWriter(os.Stderr, log.Colorize(log.LightRed, slog.LevelInfo.String()), log.Colorize(c.Color, c.Name))
 	cmd.Stdout = log.NewDuesWriter(os.Stdout, log.Colorize(log.LightCyan, slog.LevelInfo.String()), log.Colorize(c.Color, c.Name))
+	if options.streams.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, options.streams.Stderr)
+	}
+	if options.streams.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, options.streams.Stdout)
+	}
+	var stdin io.Reader
+	if c.Stdin {
+		stdin = options.streams.Stdin
+	}
 
-	err := cmd.Start()
+	started := time.Now()
+	timeout := c.StopTimeout.Or(DefaultStopTimeout)
+	var drain func() bool
+	if c.TTY {
+		drain, err = startTTY(cmd, stdin, cmd.Stdout, timeout)
+	} else {
+		cmd.Stdin = stdin
+		setProcessGroup(cmd)
+		drain, err = startPiped(cmd, timeout)
+	}
 
 	if err != nil {
-		return err
+		return Exit{}, err
 	}
 
-	cmd.Wait()
-	return nil
+	if options.track != nil {
+		options.track(cmd.Process.Pid)
+	}
+	pid := cmd.Process.Pid
+	reaped := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		if options.track != nil {
+			// the group is not signalled through the command once its leader
+			// was reaped, as the pid may be reused
+			options.track(0)
+		}
+		close(reaped)
+		if !drain() {
+			log.Logger.Warn(fmt.Sprintf("Output of command '%v' is cut off, a process it started is still writing to it after %v", c.Name, timeout))
+		}
+		close(exited)
+	}()
+
+	stopped := false
+	select {
+	case <-reaped:
+		// processes the command started in the background are stopped with
+		// it rather than left running without anything supervising them
+		if isGroupAlive(pid) {
+			log.Logger.Info(fmt.Sprintf("Command '%v' exited and left processes running in its group", c.Name))
+			c.stop(pid, exited)
+		}
+		<-exited
+	case <-ctx.Done():
+		stopped = true
+		c.stop(pid, exited)
+	}
+
+	exit := newExit(cmd.ProcessState, started, stopped)
+	c.recordExit(exit)
+	return exit, nil
+}
+
+// Equal reports whether other has the same definition as the command. Fields
+// that are not part of the config, such as the name and color, are ignored
+func (c *Command) Equal(other *Command) bool {
+	a, errA := json.Marshal(c)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
 }
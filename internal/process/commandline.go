@@ -0,0 +1,155 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// CommandLine is a command to run, written in config files either as a string
+// or as an array of strings.
+//
+// A string is a script handed as a single argument to the command's shell,
+// "sh -c" by default, so pipes, &&, redirects, globs and FOO=1 prefixes work
+// and quoting follows the rules of that shell:
+//
+//	"command": "FOO=1 go run . | tee out.log"
+//
+// ${VAR} references in a script are expanded by dues before the shell runs,
+// so their values end up in the script unquoted. Write $${VAR} to leave a
+// reference for the shell to expand. $VAR and other forms such as
+// ${VAR:-default} are never touched by dues.
+//
+// An array is the argv of the process and is executed directly without a
+// shell. Every element is passed as one argument exactly as written, so no
+// quoting or escaping is needed and nothing is expanded besides ${VAR}
+// references:
+//
+//	"command": ["go", "run", ".", "-name", "two words"]
+type CommandLine struct {
+	Script string
+	Args   []string
+}
+
+// IsEmpty reports whether there is nothing to run
+func (cl CommandLine) IsEmpty() bool {
+	return strings.TrimSpace(cl.Script) == "" && len(cl.Args) == 0
+}
+
+// IsArgv reports whether the command line is an explicit argv
+func (cl CommandLine) IsArgv() bool {
+	return cl.Args != nil
+}
+
+// trimmed removes the surrounding whitespace of a script
+func (cl CommandLine) trimmed() CommandLine {
+	if !cl.IsArgv() {
+		cl.Script = strings.TrimSpace(cl.Script)
+	}
+	return cl
+}
+
+// String returns the command line as it would be written in a terminal
+func (cl CommandLine) String() string {
+	if !cl.IsArgv() {
+		return cl.Script
+	}
+	quoted := make([]string, len(cl.Args))
+	for i, arg := range cl.Args {
+		if strings.ContainsAny(arg, "\n\r\t") {
+			// ANSI-C quoting keeps control characters on a single line
+			quoted[i] = "$'" + ansiCEscaper.Replace(arg) + "'"
+			continue
+		}
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+var ansiCEscaper = strings.NewReplacer(`\`, `\\`, "'", `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// Argv returns the arguments the command line is executed with. Scripts are
+// run through shell, or the default shell of the platform when it is empty
+func (cl CommandLine) Argv(shell string) []string {
+	if cl.IsArgv() {
+		return cl.Args
+	}
+	return append(ShellArgv(shell), cl.Script)
+}
+
+// ShellArgv splits a shell setting such as "bash -lc" into its arguments,
+// falling back to the default shell of the platform
+func ShellArgv(shell string) []string {
+	if argv := strings.Fields(shell); len(argv) > 0 {
+		return argv
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C"}
+	}
+	return []string{"sh", "-c"}
+}
+
+func (cl CommandLine) MarshalJSON() ([]byte, error) {
+	if cl.IsArgv() {
+		return json.Marshal(cl.Args)
+	}
+	return json.Marshal(cl.Script)
+}
+
+func (cl *CommandLine) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return cl.fromValue(value)
+}
+
+// UnmarshalYAML lets yaml decode both forms of a command line
+func (cl *CommandLine) UnmarshalYAML(unmarshal func(any) error) error {
+	var value any
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return cl.fromValue(value)
+}
+
+// UnmarshalTOML lets toml decode both forms of a command line
+func (cl *CommandLine) UnmarshalTOML(value any) error {
+	return cl.fromValue(value)
+}
+
+func (cl *CommandLine) fromValue(value any) error {
+	switch value := value.(type) {
+	case nil:
+		*cl = CommandLine{}
+	case string:
+		*cl = CommandLine{Script: value}
+	case []any:
+		args := make([]string, len(value))
+		for i, arg := range value {
+			str, ok := arg.(string)
+			if !ok {
+				return fmt.Errorf("argument %d of command is %v, expected a string", i, arg)
+			}
+			args[i] = str
+		}
+		*cl = CommandLine{Args: args}
+	default:
+		return errors.New("a command has to be a string or an array of strings")
+	}
+	return nil
+}
+
+func (CommandLine) JSONSchema() map[string]any {
+	return map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 1},
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package process
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration written in config files as a string such as
+// "500ms" or "1m30s"
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s', expected a value such as 500ms or 2s", text)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Or returns the duration, or fallback when it is not set
+func (d Duration) Or(fallback time.Duration) time.Duration {
+	if d == 0 {
+		return fallback
+	}
+	return time.Duration(d)
+}
+
+func (d Duration) JSONSchema() map[string]any {
+	return map[string]any{
+		"type":    "string",
+		"pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+	}
+}
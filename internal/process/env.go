@@ -0,0 +1,80 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/dotenv"
+)
+
+// Validates the env fields and resolves the env files against the cwd
+func (c *Command) processEnv() error {
+	for key := range c.Env {
+		if strings.TrimSpace(key) == "" || strings.Contains(key, "=") {
+			return errors.New(fmt.Sprintf("Command '%v' has an invalid environment variable name '%v'", c.Name, key))
+		}
+	}
+
+	for i, envFile := range c.EnvFile {
+		envFile = strings.TrimSpace(envFile)
+		if envFile == "" {
+			return errors.New(fmt.Sprintf("Command '%v' has an empty envFile entry", c.Name))
+		}
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(c.Cwd, envFile)
+		}
+		c.EnvFile[i] = filepath.Clean(envFile)
+	}
+
+	return nil
+}
+
+// IsEnvFile reports whether path is one of the command's env files
+func (c *Command) IsEnvFile(path string) bool {
+	return slices.Contains(c.EnvFile, filepath.Clean(path))
+}
+
+// Environ builds the environment the command's processes are started with.
+// The env files are read on every call so that edits to them are picked up
+// the next time the command is launched.
+func (c *Command) Environ() ([]string, error) {
+	env := make(map[string]string)
+
+	if c.CleanEnv {
+		for _, key := range c.PassEnv {
+			if value, exists := os.LookupEnv(key); exists {
+				env[key] = value
+			}
+		}
+	} else {
+		for _, pair := range os.Environ() {
+			key, value, _ := strings.Cut(pair, "=")
+			env[key] = value
+		}
+	}
+
+	for _, envFile := range c.EnvFile {
+		fileEnv, err := dotenv.ReadFile(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load env file of command '%v': %w", c.Name, err)
+		}
+		for key, value := range fileEnv {
+			env[key] = value
+		}
+	}
+
+	for key, value := range c.Env {
+		env[key] = value
+	}
+
+	environ := make([]string, 0, len(env))
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	slices.Sort(environ)
+	return environ, nil
+}
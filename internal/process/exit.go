@@ -0,0 +1,67 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Exit describes how a run of a command ended
+type Exit struct {
+	// Exit code of the process. Processes ended by a signal get 128 plus the
+	// signal number, like they would in a shell
+	Code int
+	// Signal that ended the process, empty when it exited by itself
+	Signal string
+	// How long the process ran
+	Duration time.Duration
+	// Whether dues stopped the process itself, for example on a restart
+	Stopped bool
+}
+
+// Failed reports whether the process ended unsuccessfully without dues
+// stopping it
+func (e Exit) Failed() bool {
+	return !e.Stopped && e.Code != 0
+}
+
+func (e Exit) String() string {
+	duration := e.Duration.Round(time.Millisecond)
+	if e.Signal != "" {
+		return fmt.Sprintf("was killed by %v after %v", e.Signal, duration)
+	}
+	return fmt.Sprintf("exited with code %d after %v", e.Code, duration)
+}
+
+// newExit describes the run of a process that ended in state
+func newExit(state *os.ProcessState, started time.Time, stopped bool) Exit {
+	exit := Exit{
+		Code:     state.ExitCode(),
+		Duration: time.Since(started),
+		Stopped:  stopped,
+	}
+	if signal, number, signaled := exitSignal(state); signaled {
+		exit.Signal = signal
+		exit.Code = 128 + number
+	}
+	return exit
+}
+
+// LastExit returns how the last run of any of the command fields ended. It
+// reports false when none has ended yet
+func (c *Command) LastExit() (Exit, bool) {
+	c.exitMutex.Lock()
+	defer c.exitMutex.Unlock()
+
+	if c.lastExit == nil {
+		return Exit{}, false
+	}
+	return *c.lastExit, true
+}
+
+func (c *Command) recordExit(exit Exit) {
+	c.exitMutex.Lock()
+	defer c.exitMutex.Unlock()
+
+	c.lastExit = &exit
+}
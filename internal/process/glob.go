@@ -0,0 +1,48 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchesGlob reports whether file, relative to the cwd of the command,
+// matches one of patterns. The patterns of the on and reloadOn fields are
+// matched this way, see Rule for their syntax
+func matchesGlob(patterns []string, file string) bool {
+	file = filepath.ToSlash(file)
+	for _, p := range patterns {
+		name := file
+		if !strings.Contains(p, "/") {
+			name = path.Base(file)
+		}
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeFile returns file relative to the cwd of the command, which is
+// what glob patterns are matched against
+func (c *Command) relativeFile(file string) string {
+	if relative, err := filepath.Rel(c.Cwd, file); err == nil {
+		return relative
+	}
+	return file
+}
+
+// validateGlobs checks the glob patterns of field
+func (c *Command) validateGlobs(field string, patterns []string) error {
+	for i, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v[%d]' is empty", c.Name, field, i))
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v[%d]': invalid pattern '%v': %v", c.Name, field, i, p, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultHealthcheckInterval  = 10 * time.Second
+	DefaultHealthcheckTimeout   = 5 * time.Second
+	DefaultHealthcheckThreshold = 3
+)
+
+// Healthcheck is checked periodically once the command is ready. The command
+// is restarted after FailureThreshold consecutive failures. Exactly one of
+// HTTP, TCP and Exec has to be set
+type Healthcheck struct {
+	// URL that has to answer with a 2xx status
+	HTTP string `json:"http" yaml:"http" toml:"http"`
+	// Address such as localhost:8080 that has to accept connections
+	TCP string `json:"tcp" yaml:"tcp" toml:"tcp"`
+	// Command that has to exit successfully. It runs in the cwd and
+	// environment of the command
+	Exec CommandLine `json:"exec" yaml:"exec" toml:"exec"`
+	// Time between checks. Defaults to 10s
+	Interval Duration `json:"interval" yaml:"interval" toml:"interval"`
+	// How long a single check may take. Defaults to 5s
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+	// Consecutive failures after which the command is restarted. Defaults to 3
+	FailureThreshold int `json:"failureThreshold" yaml:"failureThreshold" toml:"failureThreshold"`
+}
+
+// String describes what the healthcheck checks
+func (h *Healthcheck) String() string {
+	switch {
+	case h.HTTP != "":
+		return "http " + h.HTTP
+	case h.TCP != "":
+		return "tcp " + h.TCP
+	default:
+		return "exec " + h.Exec.String()
+	}
+}
+
+// Threshold returns the number of consecutive failures after which the
+// command is restarted
+func (h *Healthcheck) Threshold() int {
+	if h.FailureThreshold == 0 {
+		return DefaultHealthcheckThreshold
+	}
+	return h.FailureThreshold
+}
+
+// Validates the healthcheck field
+func (c *Command) processHealthcheck() error {
+	if c.Healthcheck == nil {
+		return nil
+	}
+	healthcheck := c.Healthcheck
+	healthcheck.HTTP = strings.TrimSpace(healthcheck.HTTP)
+	healthcheck.TCP = strings.TrimSpace(healthcheck.TCP)
+	healthcheck.Exec = healthcheck.Exec.trimmed()
+
+	set := 0
+	for _, kind := range []bool{healthcheck.HTTP != "", healthcheck.TCP != "", !healthcheck.Exec.IsEmpty()} {
+		if kind {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New(fmt.Sprintf("Command '%v' healthcheck has to set exactly one of http, tcp and exec", c.Name))
+	}
+
+	if healthcheck.HTTP != "" {
+		if parsed, err := url.Parse(healthcheck.HTTP); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New(fmt.Sprintf("Command '%v' healthcheck has an invalid http url '%v'", c.Name, healthcheck.HTTP))
+		}
+	}
+	if healthcheck.TCP != "" {
+		if _, _, err := net.SplitHostPort(healthcheck.TCP); err != nil {
+			return errors.New(fmt.Sprintf("Command '%v' healthcheck has an invalid tcp address '%v': %v", c.Name, healthcheck.TCP, err))
+		}
+	}
+	if healthcheck.FailureThreshold < 0 {
+		return errors.New(fmt.Sprintf("Command '%v' healthcheck has a negative failureThreshold", c.Name))
+	}
+	return nil
+}
+
+// RunHealthcheckCommand runs the exec healthcheck of the command in its cwd
+// and environment. Its output is only used to describe a failure
+func (c *Command) RunHealthcheckCommand(ctx context.Context) error {
+	argv := c.argv(c.Healthcheck.Exec)
+	if len(argv) == 0 {
+		return errors.New("the healthcheck has no exec field")
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = c.Cwd
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return signalGroup(cmd.Process.Pid, killSignal)
+	}
+	cmd.WaitDelay = time.Second
+
+	env, err := c.Environ()
+	if err != nil {
+		return err
+	}
+	cmd.Env = env
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if lines := bytes.Split(bytes.TrimSpace(output), []byte("\n")); len(lines[len(lines)-1]) > 0 {
+			return fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/interpolate"
+)
+
+// interpolate expands variable references in the command's fields. The cwd
+// field is expanded and resolved first so that ${cwd} is available to the
+// remaining fields.
+func (c *Command) interpolate(configPath string, vars interpolate.Lookup) error {
+	lookup := interpolate.Chain(interpolate.Map(map[string]string{
+		"configDir": filepath.Dir(configPath),
+		"name":      c.Name,
+	}), vars)
+
+	if err := c.expandField("cwd", &c.Cwd, lookup); err != nil {
+		return err
+	}
+	if err := c.processCwd(configPath); err != nil {
+		return err
+	}
+
+	lookup = interpolate.Chain(interpolate.Map(map[string]string{"cwd": c.Cwd}), lookup)
+
+	commandLines := map[string]*CommandLine{
+		"command":     &c.Command,
+		"preCommand":  &c.PreCommand,
+		"postCommand": &c.PostCommand,
+	}
+	for _, field := range []string{"command", "preCommand", "postCommand"} {
+		if err := c.expandCommandLine(field, commandLines[field], lookup); err != nil {
+			return err
+		}
+	}
+	for i := range c.Steps {
+		if err := c.expandCommandLine("steps["+strconv.Itoa(i)+"]", &c.Steps[i], lookup); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.On {
+		field := "on[" + strconv.Itoa(i) + "]"
+		for j := range c.On[i].Match {
+			if err := c.expandField(field+".match["+strconv.Itoa(j)+"]", &c.On[i].Match[j], lookup); err != nil {
+				return err
+			}
+		}
+		if err := c.expandCommandLine(field+".run", &c.On[i].Run, lookup); err != nil {
+			return err
+		}
+	}
+
+	if err := c.expandField("shell", &c.Shell, lookup); err != nil {
+		return err
+	}
+
+	if c.Healthcheck != nil {
+		if err := c.expandField("healthcheck.http", &c.Healthcheck.HTTP, lookup); err != nil {
+			return err
+		}
+		if err := c.expandField("healthcheck.tcp", &c.Healthcheck.TCP, lookup); err != nil {
+			return err
+		}
+		if err := c.expandCommandLine("healthcheck.exec", &c.Healthcheck.Exec, lookup); err != nil {
+			return err
+		}
+	}
+
+	if c.Ready != nil {
+		readyFields := map[string]*string{
+			"ready.tcp":  &c.Ready.TCP,
+			"ready.http": &c.Ready.HTTP,
+			"ready.log":  &c.Ready.Log,
+			"ready.file": &c.Ready.File,
+		}
+		for _, field := range []string{"ready.tcp", "ready.http", "ready.log", "ready.file"} {
+			if err := c.expandField(field, readyFields[field], lookup); err != nil {
+				return err
+			}
+		}
+	}
+
+	lists := map[string][]string{
+		"ignore":   c.Ignore,
+		"include":  c.Include,
+		"envFile":  c.EnvFile,
+		"reloadOn": c.ReloadOn,
+	}
+	for _, field := range []string{"ignore", "include", "envFile", "reloadOn"} {
+		for i := range lists[field] {
+			if err := c.expandField(field+"["+strconv.Itoa(i)+"]", &lists[field][i], lookup); err != nil {
+				return err
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(c.Env))
+	for key := range c.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := c.Env[key]
+		if err := c.expandField("env."+key, &value, lookup); err != nil {
+			return err
+		}
+		c.Env[key] = value
+	}
+
+	return nil
+}
+
+// expandCommandLine expands the variable references of a script or of every
+// argument of an argv command line
+func (c *Command) expandCommandLine(field string, commandLine *CommandLine, lookup interpolate.Lookup) error {
+	if err := c.expandField(field, &commandLine.Script, lookup); err != nil {
+		return err
+	}
+	for i := range commandLine.Args {
+		if err := c.expandField(field+"["+strconv.Itoa(i)+"]", &commandLine.Args[i], lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandField expands the variable references of a single field in place
+func (c *Command) expandField(field string, value *string, lookup interpolate.Lookup) error {
+	expanded, err := interpolate.Expand(*value, lookup)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Command '%v' field '%v': %v", c.Name, field, err))
+	}
+	*value = expanded
+	return nil
+}
@@ -0,0 +1,55 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Override returns a copy of the command with fields replaced by the values in
+// overrides, which is keyed by the same names used in config files. Nested
+// objects such as env are merged key by key while lists are replaced.
+func (c *Command) Override(overrides map[string]any) (*Command, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	MergeFields(fields, overrides)
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overrides for command '%v': %w", c.Name, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	overridden := &Command{}
+	if err := decoder.Decode(overridden); err != nil {
+		return nil, fmt.Errorf("invalid overrides for command '%v': %w", c.Name, err)
+	}
+
+	overridden.Name = c.Name
+	overridden.Source = c.Source
+	overridden.Color = c.Color
+	return overridden, nil
+}
+
+// MergeFields merges overrides into fields, recursing into objects present
+// in both
+func MergeFields(fields map[string]any, overrides map[string]any) {
+	for key, override := range overrides {
+		overrideObject, overrideIsObject := override.(map[string]any)
+		fieldObject, fieldIsObject := fields[key].(map[string]any)
+		if overrideIsObject && fieldIsObject {
+			MergeFields(fieldObject, overrideObject)
+			continue
+		}
+		fields[key] = override
+	}
+}
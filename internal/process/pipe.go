@@ -0,0 +1,80 @@
+package process
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// startPiped starts cmd with pipes of its own for stdout and stderr, which are
+// copied to the writers cmd was set up with. Unlike the pipes exec creates,
+// waiting for cmd does not wait for them to be closed, so the exit of the
+// command is seen even when a process it started in the background still
+// holds its output. The returned function waits up to timeout for the output
+// to be drained and closes the pipes, it has to be called once cmd exited. It
+// reports false when output was still left after timeout
+func startPiped(cmd *exec.Cmd, timeout time.Duration) (func() bool, error) {
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+	outRead, outWrite, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errRead, errWrite, err := os.Pipe()
+	if err != nil {
+		outRead.Close()
+		outWrite.Close()
+		return nil, err
+	}
+
+	cmd.Stdout = outWrite
+	cmd.Stderr = errWrite
+	err = cmd.Start()
+	// the command has its own copies of the writing ends
+	outWrite.Close()
+	errWrite.Close()
+	if err != nil {
+		outRead.Close()
+		errRead.Close()
+		return nil, err
+	}
+
+	var copies sync.WaitGroup
+	copies.Add(2)
+	go func() {
+		defer copies.Done()
+		io.Copy(stdout, outRead)
+	}()
+	go func() {
+		defer copies.Done()
+		io.Copy(stderr, errRead)
+	}()
+	drained := make(chan struct{})
+	go func() {
+		copies.Wait()
+		close(drained)
+	}()
+
+	return func() bool {
+		// closing the pipes ends the copies, as a process that is not part
+		// of the group of the command may keep them open for good
+		defer outRead.Close()
+		defer errRead.Close()
+		return waitDrained(drained, timeout)
+	}, nil
+}
+
+// waitDrained waits for drained to be closed. It reports false if that did
+// not happen within timeout
+func waitDrained(drained <-chan struct{}, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	select {
+	case <-drained:
+		return true
+	case <-deadline.C:
+		return false
+	}
+}
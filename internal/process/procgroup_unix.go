@@ -0,0 +1,92 @@
+//go:build !windows
+
+package process
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup makes the command the leader of a new process group, so
+// that every process it spawns can be signalled together
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup sends signal to every process in the group led by pid
+func signalGroup(pid int, signal os.Signal) error {
+	err := syscall.Kill(-pid, signal.(syscall.Signal))
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
+
+// isGroupAlive reports whether any process of the group led by pid is still
+// running. Zombies, which exited but were not reaped by their parent yet, can
+// still be signalled and are left out by looking at their state in /proc
+func isGroupAlive(pid int) bool {
+	if syscall.Kill(-pid, 0) != nil {
+		return false
+	}
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		// without /proc, as on macOS, zombies cannot be told apart
+		return true
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		if state, group, ok := parseStat(stat); ok && group == pid && state != "Z" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStat returns the state and process group of a /proc/<pid>/stat file.
+// The name of the process is skipped up to its last parenthesis as it may
+// contain spaces and parentheses itself
+func parseStat(stat []byte) (string, int, bool) {
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return "", 0, false
+	}
+	// state, parent pid and process group follow the name
+	fields := bytes.Fields(stat[end+1:])
+	if len(fields) < 3 {
+		return "", 0, false
+	}
+	group, err := strconv.Atoi(string(fields[2]))
+	if err != nil {
+		return "", 0, false
+	}
+	return string(fields[0]), group, true
+}
+
+var killSignal os.Signal = syscall.SIGKILL
+
+// exitSignal returns the name and number of the signal that ended the process
+// of state, if it was ended by one
+func exitSignal(state *os.ProcessState) (string, int, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", 0, false
+	}
+	signal := status.Signal()
+	for name, known := range signals {
+		if known == signal {
+			return name, int(signal), true
+		}
+	}
+	return signal.String(), int(signal), true
+}
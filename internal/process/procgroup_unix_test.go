@@ -0,0 +1,67 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseStat(t *testing.T) {
+	tests := []struct {
+		name      string
+		stat      string
+		wantState string
+		wantGroup int
+		wantOk    bool
+	}{
+		{"running process", "42 (sleep) S 1 40 40 0 -1", "S", 40, true},
+		{"zombie", "43 (sh) Z 42 40 40 0 -1", "Z", 40, true},
+		{"name with spaces and parentheses", "44 (a (b) c) R 1 44 44 0 -1", "R", 44, true},
+		{"truncated", "45 (sleep) S 1", "", 0, false},
+		{"no name", "46 S 1 46", "", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, group, ok := parseStat([]byte(test.stat))
+			if state != test.wantState || group != test.wantGroup || ok != test.wantOk {
+				t.Errorf("parseStat(%q) = %q, %d, %v, want %q, %d, %v", test.stat, state, group, ok, test.wantState, test.wantGroup, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestRunStopsLeftoverProcesses(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	c := &Command{Name: "leftover", StopTimeout: Duration(time.Second)}
+
+	_, err := c.runCmd([]string{"sh", "-c", "sleep 30 & echo $! > " + pidFile}, context.Background())
+	if err != nil {
+		t.Fatalf("runCmd returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("could not read the pid of the background process: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("invalid pid %q: %v", data, err)
+	}
+	// the background process was reparented once its shell exited, so it may
+	// be left as a zombie until its new parent reaps it
+	if err := syscall.Kill(pid, 0); err == nil {
+		if stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat")); err == nil {
+			if state, _, ok := parseStat(stat); ok && state != "Z" {
+				t.Errorf("background process %d is still %v after its command exited", pid, state)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup makes the command the root of a new process group
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalGroup ends the process tree rooted at pid. Windows has no way of
+// delivering other signals to a tree, so every signal terminates it
+func signalGroup(pid int, signal os.Signal) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+// isGroupAlive always reports false as the tree is killed at once
+func isGroupAlive(pid int) bool {
+	return false
+}
+
+var killSignal os.Signal = os.Kill
+
+// exitSignal always reports false as Windows processes do not end by signals
+func exitSignal(state *os.ProcessState) (string, int, bool) {
+	return "", 0, false
+}
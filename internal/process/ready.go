@@ -0,0 +1,93 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultReadyTimeout  = 30 * time.Second
+	DefaultProbeInterval = 250 * time.Millisecond
+)
+
+// ReadyProbe tells when a started command is ready to be used, which is when
+// the commands depending on it are started. Exactly one of TCP, HTTP, Log and
+// File has to be set
+type ReadyProbe struct {
+	// Address such as localhost:8080 that accepts connections once ready
+	TCP string `json:"tcp" yaml:"tcp" toml:"tcp"`
+	// URL answering with a 2xx status once ready
+	HTTP string `json:"http" yaml:"http" toml:"http"`
+	// Regular expression matched against every line the command writes to
+	// stdout or stderr
+	Log string `json:"log" yaml:"log" toml:"log"`
+	// File that exists once ready, relative paths are resolved against Cwd
+	File string `json:"file" yaml:"file" toml:"file"`
+	// How long the command has to become ready. Defaults to 30s. The commands
+	// depending on it are not started when the first run of the command does
+	// not become ready in time
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+	// How often the probe is checked. Defaults to 250ms
+	Interval Duration `json:"interval" yaml:"interval" toml:"interval"`
+}
+
+// String describes what the probe waits for
+func (rp *ReadyProbe) String() string {
+	switch {
+	case rp.TCP != "":
+		return "tcp " + rp.TCP
+	case rp.HTTP != "":
+		return "http " + rp.HTTP
+	case rp.Log != "":
+		return fmt.Sprintf("log /%v/", rp.Log)
+	default:
+		return "file " + rp.File
+	}
+}
+
+// Validates the ready field and resolves its file against Cwd
+func (c *Command) processReady() error {
+	if c.Ready == nil {
+		return nil
+	}
+	probe := c.Ready
+	probe.TCP = strings.TrimSpace(probe.TCP)
+	probe.HTTP = strings.TrimSpace(probe.HTTP)
+	probe.File = strings.TrimSpace(probe.File)
+
+	set := 0
+	for _, kind := range []string{probe.TCP, probe.HTTP, probe.Log, probe.File} {
+		if kind != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New(fmt.Sprintf("Command '%v' ready probe has to set exactly one of tcp, http, log and file", c.Name))
+	}
+
+	switch {
+	case probe.TCP != "":
+		if _, _, err := net.SplitHostPort(probe.TCP); err != nil {
+			return errors.New(fmt.Sprintf("Command '%v' ready probe has an invalid tcp address '%v': %v", c.Name, probe.TCP, err))
+		}
+	case probe.HTTP != "":
+		if parsed, err := url.Parse(probe.HTTP); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New(fmt.Sprintf("Command '%v' ready probe has an invalid http url '%v'", c.Name, probe.HTTP))
+		}
+	case probe.Log != "":
+		if _, err := regexp.Compile(probe.Log); err != nil {
+			return errors.New(fmt.Sprintf("Command '%v' ready probe has an invalid log pattern: %v", c.Name, err))
+		}
+	case probe.File != "":
+		if !filepath.IsAbs(probe.File) {
+			probe.File = filepath.Join(c.Cwd, probe.File)
+		}
+	}
+	return nil
+}
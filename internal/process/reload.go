@@ -0,0 +1,53 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultReloadSignal is sent by commands whose onBusy field is signal when
+// they do not set a reloadSignal
+const DefaultReloadSignal = "SIGHUP"
+
+// Validates the reload fields. It runs after the onBusy field is processed
+func (c *Command) processReload() error {
+	if err := c.validateGlobs("reloadOn", c.ReloadOn); err != nil {
+		return err
+	}
+	if strings.TrimSpace(c.ReloadSignal) == "" {
+		c.ReloadSignal = ""
+		if len(c.ReloadOn) > 0 {
+			return errors.New(fmt.Sprintf("Command '%v' sets reloadOn without a reloadSignal", c.Name))
+		}
+		if c.OnBusy != OnBusySignal {
+			return nil
+		}
+		c.ReloadSignal = DefaultReloadSignal
+	}
+
+	if _, err := ParseSignal(c.ReloadSignal); err != nil {
+		return errors.New(fmt.Sprintf("Command '%v' has an invalid reloadSignal field: %v", c.Name, err))
+	}
+	c.ReloadSignal = signalName(c.ReloadSignal)
+	return nil
+}
+
+// ReloadsOn reports whether changes to paths are handled by sending the
+// reload signal to the running command instead of restarting it, which is
+// the case when every path matches one of the reloadOn patterns. They are
+// matched like the patterns of rules, see Rule
+func (c *Command) ReloadsOn(paths []string) bool {
+	if c.ReloadSignal == "" || len(paths) == 0 {
+		return false
+	}
+	if len(c.ReloadOn) == 0 {
+		return true
+	}
+	for _, path := range paths {
+		if !matchesGlob(c.ReloadOn, c.relativeFile(path)) {
+			return false
+		}
+	}
+	return true
+}
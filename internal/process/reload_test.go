@@ -0,0 +1,44 @@
+package process
+
+import "testing"
+
+func TestReloadsOn(t *testing.T) {
+	command := &Command{
+		Cwd:          "/project",
+		ReloadSignal: "SIGHUP",
+		ReloadOn:     []string{"*.html", "static/*.css"},
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  bool
+	}{
+		{"matching base name", []string{"/project/templates/index.html"}, true},
+		{"matching relative path", []string{"/project/static/site.css"}, true},
+		{"whole name only", []string{"/project/templates/index.html.orig"}, false},
+		{"relative path in another directory", []string{"/project/web/static/site.css"}, false},
+		{"every path matches", []string{"/project/a.html", "/project/static/b.css"}, true},
+		{"one path does not match", []string{"/project/a.html", "/project/main.go"}, false},
+		{"no paths", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := command.ReloadsOn(test.paths); got != test.want {
+				t.Errorf("ReloadsOn(%q) = %v, want %v", test.paths, got, test.want)
+			}
+		})
+	}
+}
+
+func TestProcessReloadInvalidPattern(t *testing.T) {
+	command := &Command{Name: "web", ReloadSignal: "SIGHUP", ReloadOn: []string{"*.html", "[a-"}}
+	err := command.processReload()
+	if err == nil {
+		t.Fatal("processReload accepted an invalid reloadOn pattern")
+	}
+	if got, want := err.Error(), "Command 'web' field 'reloadOn[1]': invalid pattern '[a-': syntax error in pattern"; got != want {
+		t.Errorf("processReload() error = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RestartPolicy decides whether a command that exited by itself is started
+// again without waiting for a file change
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+const (
+	DefaultRestartDelay    = 1 * time.Second
+	DefaultRestartMaxDelay = 30 * time.Second
+	DefaultCrashLoopCount  = 5
+	DefaultCrashLoopWindow = 1 * time.Minute
+)
+
+var restartPolicies = []RestartPolicy{RestartNever, RestartOnFailure, RestartAlways}
+
+// ShouldRestart reports whether a run that ended with exit is restarted
+// under the policy
+func (rp RestartPolicy) ShouldRestart(exit Exit) bool {
+	if exit.Stopped {
+		return false
+	}
+	switch rp {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exit.Failed()
+	default:
+		return false
+	}
+}
+
+func (rp RestartPolicy) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": restartPolicies,
+	}
+}
+
+// Validates the restart fields and fills in the default policy
+func (c *Command) processRestart() error {
+	c.Restart = RestartPolicy(strings.ToLower(strings.TrimSpace(string(c.Restart))))
+	if c.Restart == "" {
+		c.Restart = RestartNever
+	}
+
+	known := false
+	for _, policy := range restartPolicies {
+		known = known || c.Restart == policy
+	}
+	if !known {
+		return errors.New(fmt.Sprintf("Command '%v' has an unknown restart policy '%v', expected never, on-failure or always", c.Name, c.Restart))
+	}
+
+	if c.MaxRestarts < 0 {
+		return errors.New(fmt.Sprintf("Command '%v' has a negative maxRestarts field", c.Name))
+	}
+	if c.CrashLoopCount < 0 {
+		return errors.New(fmt.Sprintf("Command '%v' has a negative crashLoopCount field", c.Name))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RuleAction is what a change to a file matched by a rule does
+type RuleAction string
+
+const (
+	// Restart the main command
+	RuleRestart RuleAction = "restart"
+	// Send the reload signal to the running main command
+	RuleReload RuleAction = "reload"
+	// Run the command line of the rule and leave the main command alone
+	RuleRun RuleAction = "run"
+	// Do nothing
+	RuleIgnore RuleAction = "ignore"
+)
+
+var ruleActions = []RuleAction{RuleRestart, RuleReload, RuleRun, RuleIgnore}
+
+func (ra RuleAction) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": ruleActions,
+	}
+}
+
+// Rule maps the files matching its patterns to an action. The rules of a
+// command are evaluated in order and a file is handled by the first rule that
+// matches it. Files no rule matches restart the command, or reload it when
+// they match ReloadOn.
+//
+// Match patterns use the syntax of path.Match and have to match the whole
+// name, like the patterns of ReloadOn: * matches any characters but /, ?
+// matches a single one and [a-z] matches a class. Patterns without a / are
+// matched against the base name of the file, so *.go matches every Go file
+// but not main.go.orig. Patterns with a / are matched against the path
+// relative to Cwd, such as api/*.proto
+type Rule struct {
+	// Patterns of the files the rule applies to, such as *.go or api/*.proto
+	Match []string `json:"match" yaml:"match" toml:"match"`
+	// What a change to a matching file does: restart, reload, run or ignore.
+	// Defaults to run when Run is set and to restart otherwise
+	Action RuleAction `json:"action" yaml:"action" toml:"action"`
+	// Command line of the run action. The files the rule matched replace the
+	// {{changed}} placeholder
+	Run CommandLine `json:"run" yaml:"run" toml:"run"`
+}
+
+// Matches reports whether file, relative to the cwd of the command, is one of
+// the files the rule applies to
+func (r Rule) Matches(file string) bool {
+	return matchesGlob(r.Match, file)
+}
+
+func (r Rule) String() string {
+	if r.Action == RuleRun {
+		return fmt.Sprintf("%v: run %v", strings.Join(r.Match, ", "), r.Run)
+	}
+	return fmt.Sprintf("%v: %v", strings.Join(r.Match, ", "), r.Action)
+}
+
+// Validates the rules and fills in their default actions
+func (c *Command) processRules() error {
+	for i := range c.On {
+		rule := &c.On[i]
+		field := "on[" + strconv.Itoa(i) + "]"
+		rule.Run = rule.Run.trimmed()
+
+		rule.Action = RuleAction(strings.ToLower(strings.TrimSpace(string(rule.Action))))
+		if rule.Action == "" {
+			rule.Action = RuleRestart
+			if !rule.Run.IsEmpty() {
+				rule.Action = RuleRun
+			}
+		}
+
+		known := false
+		for _, action := range ruleActions {
+			known = known || rule.Action == action
+		}
+		if !known {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v' has an unknown action '%v', expected restart, reload, run or ignore", c.Name, field, rule.Action))
+		}
+
+		if len(rule.Match) == 0 {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v' has no match patterns", c.Name, field))
+		}
+		if err := c.validateGlobs(field+".match", rule.Match); err != nil {
+			return err
+		}
+		if rule.Action == RuleRun && rule.Run.IsEmpty() {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v' runs an empty command", c.Name, field))
+		}
+		if rule.Action != RuleRun && !rule.Run.IsEmpty() {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v' sets run along with the %v action", c.Name, field, rule.Action))
+		}
+		if rule.Action == RuleReload && c.ReloadSignal == "" {
+			return errors.New(fmt.Sprintf("Command '%v' field '%v' reloads the command without a reloadSignal", c.Name, field))
+		}
+	}
+	return nil
+}
+
+// RuleFor returns the index of the first rule matching file, or -1 when none
+// does
+func (c *Command) RuleFor(file string) int {
+	file = c.relativeFile(file)
+	for i, rule := range c.On {
+		if rule.Matches(file) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Launches the command line of a run rule. trigger is handed to the run
+func (c *Command) LaunchRule(ctx context.Context, rule Rule, trigger Trigger) (Exit, error) {
+	return c.runCmdWith(c.argv(trigger.expand(rule.Run)), ctx, runOptions{env: trigger.environ()})
+}
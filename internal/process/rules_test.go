@@ -0,0 +1,37 @@
+package process
+
+import "testing"
+
+func TestRuleFor(t *testing.T) {
+	command := &Command{
+		Cwd: "/project",
+		On: []Rule{
+			{Match: []string{"*.go"}},
+			{Match: []string{"api/*.proto", "*.sql"}},
+			{Match: []string{"*.cs?"}},
+		},
+	}
+
+	tests := []struct {
+		file string
+		want int
+	}{
+		{"/project/main.go", 0},
+		{"/project/internal/server/server.go", 0},
+		{"/project/.golangci.yml", -1},
+		{"/project/main.go.orig", -1},
+		{"/project/api/user.proto", 1},
+		{"/project/api/v1/user.proto", -1},
+		{"/project/other/user.proto", -1},
+		{"/project/db/schema.sql", 1},
+		{"/project/web/site.css", 2},
+		{"/project/web/site.css.map", -1},
+		{"/elsewhere/main.go", 0},
+	}
+
+	for _, test := range tests {
+		if got := command.RuleFor(test.file); got != test.want {
+			t.Errorf("RuleFor(%q) = %d, want %d", test.file, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package process
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// posixShells are the shells whose scripts scriptExecutable knows how to read
+var posixShells = []string{"sh", "bash", "dash", "zsh", "ksh", "ash"}
+
+// shellKeywords start compound commands, after which the executable is not
+// the first word anymore
+var shellKeywords = []string{"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done", "case", "esac", "select", "function", "time", "!", "{", "}", "[[", "]]"}
+
+// shellBuiltins are run by the shell itself and need no executable
+var shellBuiltins = []string{".", ":", "[", "alias", "cd", "command", "echo", "eval", "exit", "export", "false", "kill", "printf", "pwd", "read", "return", "set", "shift", "source", "test", "trap", "true", "type", "ulimit", "umask", "unset", "wait"}
+
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// isPOSIXShell reports whether shell runs scripts with POSIX shell syntax
+func isPOSIXShell(shell string) bool {
+	if runtime.GOOS == "windows" && strings.TrimSpace(shell) == "" {
+		return false
+	}
+	executable := filepath.Base(ShellArgv(shell)[0])
+	return slices.Contains(posixShells, strings.TrimSuffix(executable, ".exe"))
+}
+
+// scriptExecutable reads the executable a POSIX shell script runs first,
+// skipping FOO=bar assignments and exec. It returns an empty string when the
+// script starts with a shell builtin, and false when the script is too complex
+// to tell without running it, such as when its first word is quoted, contains
+// an expansion or starts a compound command.
+func scriptExecutable(script string) (string, bool) {
+	for _, word := range strings.Fields(script) {
+		if match := assignment.FindString(word); match != "" {
+			if strings.ContainsAny(word[len(match):], "'\"`\\(") {
+				return "", false
+			}
+			continue
+		}
+		if strings.ContainsAny(word, "'\"`\\$(){}<>|&;*?[]~#") && word != "[" {
+			return "", false
+		}
+		if slices.Contains(shellKeywords, word) {
+			return "", false
+		}
+		if word == "exec" {
+			continue
+		}
+		if slices.Contains(shellBuiltins, word) {
+			return "", true
+		}
+		return word, true
+	}
+	return "", true
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultStopSignal  = "SIGTERM"
+	DefaultStopTimeout = 5 * time.Second
+)
+
+// signalName normalizes name to its SIG prefixed upper case form, so that
+// "term", "TERM" and "SIGTERM" all name the same signal
+func signalName(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	return name
+}
+
+// ParseSignal returns the signal called name, such as "SIGTERM" or "INT"
+func ParseSignal(name string) (os.Signal, error) {
+	signal, exists := signals[signalName(name)]
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("unknown signal '%v'", name))
+	}
+	return signal, nil
+}
+
+// Validates the stop signal field and fills in the default one
+func (c *Command) processStopSignal() error {
+	if strings.TrimSpace(c.StopSignal) == "" {
+		return nil
+	}
+	if _, err := ParseSignal(c.StopSignal); err != nil {
+		return errors.New(fmt.Sprintf("Command '%v' has an invalid stopSignal field: %v", c.Name, err))
+	}
+	c.StopSignal = signalName(c.StopSignal)
+	return nil
+}
+
+// stopSignal returns the name and value of the signal the command is
+// stopped with
+func (c *Command) stopSignal() (string, os.Signal) {
+	name := DefaultStopSignal
+	if c.StopSignal != "" {
+		name = signalName(c.StopSignal)
+	}
+	signal, err := ParseSignal(name)
+	if err != nil {
+		return DefaultStopSignal, signals[DefaultStopSignal]
+	}
+	return name, signal
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+)
+
+// signals are the signals a command can be stopped with. Windows can only
+// interrupt or kill a process
+var signals = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGKILL": os.Kill,
+	"SIGTERM": os.Kill,
+}
@@ -0,0 +1,49 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Validates the steps field. A command either has a command field or steps,
+// and none of its steps can be empty
+func (c *Command) processSteps() error {
+	if len(c.Steps) == 0 {
+		return nil
+	}
+	if !c.Command.IsEmpty() {
+		return errors.New(fmt.Sprintf("Command '%v' has both a command field and steps, only one of them can be set", c.Name))
+	}
+	for i := range c.Steps {
+		c.Steps[i] = c.Steps[i].trimmed()
+		if c.Steps[i].IsEmpty() {
+			return errors.New(fmt.Sprintf("Command '%v' has an empty step %d", c.Name, i+1))
+		}
+	}
+	return nil
+}
+
+// BuildSteps returns the steps that have to succeed before the long running
+// step replaces the running instance of the command. It is empty for
+// commands without steps
+func (c *Command) BuildSteps() []CommandLine {
+	if len(c.Steps) == 0 {
+		return nil
+	}
+	return c.Steps[:len(c.Steps)-1]
+}
+
+// mainStep returns the long running command line, which is the last of the
+// steps for commands that have them
+func (c *Command) mainStep() CommandLine {
+	if len(c.Steps) == 0 {
+		return c.Command
+	}
+	return c.Steps[len(c.Steps)-1]
+}
+
+// Launches one of the build steps. trigger is handed to the run
+func (c *Command) LaunchStep(ctx context.Context, step CommandLine, trigger Trigger) (Exit, error) {
+	return c.runCmdWith(c.argv(trigger.expand(step)), ctx, runOptions{env: trigger.environ()})
+}
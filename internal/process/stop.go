@@ -0,0 +1,57 @@
+package process
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+)
+
+// stop sends the stop signal to the process group led by pid and waits for
+// the group to exit. Whatever is still running once the stop timeout passes
+// is killed. exited is closed once the leader of the group has been reaped
+func (c *Command) stop(pid int, exited <-chan struct{}) {
+	signalName, signal := c.stopSignal()
+	timeout := c.StopTimeout.Or(DefaultStopTimeout)
+
+	log.Logger.Info(fmt.Sprintf("Stopping command '%v' (pid %v) with %v", c.Name, pid, signalName))
+	if err := signalGroup(pid, signal); err != nil {
+		log.Logger.Error(fmt.Sprintf("Could not send %v to command '%v': %v", signalName, c.Name, err))
+	}
+
+	if c.waitForGroup(pid, exited, timeout) {
+		log.Logger.Info(fmt.Sprintf("Command '%v' stopped", c.Name))
+		return
+	}
+
+	log.Logger.Info(fmt.Sprintf("Command '%v' did not stop within %v, sending SIGKILL", c.Name, timeout))
+	if err := signalGroup(pid, killSignal); err != nil {
+		log.Logger.Error(fmt.Sprintf("Could not kill command '%v': %v", c.Name, err))
+	}
+	<-exited
+	log.Logger.Info(fmt.Sprintf("Command '%v' killed", c.Name))
+}
+
+// waitForGroup waits until the leader has exited and no other process of its
+// group is left. It reports false if that did not happen within timeout
+func (c *Command) waitForGroup(pid int, exited <-chan struct{}, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	select {
+	case <-exited:
+	case <-deadline.C:
+		return false
+	}
+
+	for isGroupAlive(pid) {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package process
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Reasons a run of the main command was started for
+const (
+	TriggerStart       = "start"
+	TriggerChange      = "change"
+	TriggerDependency  = "dependency"
+	TriggerRestart     = "restart"
+	TriggerHealthcheck = "healthcheck"
+)
+
+// ChangedPlaceholder is replaced with the changed files in the command lines
+// of the steps and of the main command
+const ChangedPlaceholder = "{{changed}}"
+
+// Trigger tells a run of the command why it was started. It is handed to the
+// run through the DUES_TRIGGER, DUES_CHANGED_FILES and DUES_RESTART_COUNT
+// environment variables and the {{changed}} placeholder
+type Trigger struct {
+	// What started the run, one of the Trigger constants
+	Reason string
+	// Files that changed since the previous run, in the order they changed
+	ChangedFiles []string
+	// How many times the command was started again before this run
+	RestartCount int
+}
+
+// environ returns the variables describing the trigger
+func (t Trigger) environ() []string {
+	return []string{
+		"DUES_TRIGGER=" + t.Reason,
+		"DUES_CHANGED_FILES=" + strings.Join(t.ChangedFiles, "\n"),
+		"DUES_RESTART_COUNT=" + strconv.Itoa(t.RestartCount),
+	}
+}
+
+// expand replaces the {{changed}} placeholder in commandLine. In a script it
+// becomes the quoted changed files separated by spaces. In an argv an argument
+// that is exactly the placeholder becomes one argument per file, and the
+// placeholder inside other arguments becomes the files separated by spaces
+func (t Trigger) expand(commandLine CommandLine) CommandLine {
+	if !commandLine.IsArgv() {
+		quoted := CommandLine{Args: t.ChangedFiles}.String()
+		commandLine.Script = strings.ReplaceAll(commandLine.Script, ChangedPlaceholder, quoted)
+		return commandLine
+	}
+
+	args := make([]string, 0, len(commandLine.Args))
+	for _, arg := range commandLine.Args {
+		if arg == ChangedPlaceholder {
+			args = append(args, t.ChangedFiles...)
+			continue
+		}
+		args = append(args, strings.ReplaceAll(arg, ChangedPlaceholder, strings.Join(t.ChangedFiles, " ")))
+	}
+	return CommandLine{Args: args}
+}
@@ -0,0 +1,47 @@
+package process
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTriggerExpand(t *testing.T) {
+	changed := []string{"/src/main.go", "/src/my file.go", "/src/it's.go"}
+
+	tests := []struct {
+		name  string
+		files []string
+		input CommandLine
+		want  CommandLine
+	}{
+		{"script without placeholder", changed, CommandLine{Script: "go test ./..."}, CommandLine{Script: "go test ./..."}},
+		{"script quotes every file", changed, CommandLine{Script: "gofmt -l {{changed}}"}, CommandLine{Script: `gofmt -l /src/main.go '/src/my file.go' '/src/it'\''s.go'`}},
+		{"script with several placeholders", []string{"a.go"}, CommandLine{Script: "echo {{changed}} && lint {{changed}}"}, CommandLine{Script: "echo a.go && lint a.go"}},
+		{"script without changed files", nil, CommandLine{Script: "lint {{changed}}"}, CommandLine{Script: "lint "}},
+		{"script with shell metacharacters", []string{"$(rm -rf x).go", "a;b.go"}, CommandLine{Script: "lint {{changed}}"}, CommandLine{Script: `lint '$(rm -rf x).go' 'a;b.go'`}},
+		{"argv placeholder argument", changed, CommandLine{Args: []string{"gofmt", "-l", "{{changed}}"}}, CommandLine{Args: []string{"gofmt", "-l", "/src/main.go", "/src/my file.go", "/src/it's.go"}}},
+		{"argv placeholder argument without files", nil, CommandLine{Args: []string{"lint", "{{changed}}"}}, CommandLine{Args: []string{"lint"}}},
+		{"argv placeholder inside argument", []string{"a.go", "b.go"}, CommandLine{Args: []string{"lint", "--files={{changed}}"}}, CommandLine{Args: []string{"lint", "--files=a.go b.go"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Trigger{Reason: TriggerChange, ChangedFiles: test.files}.expand(test.input)
+			if got.Script != test.want.Script || !slices.Equal(got.Args, test.want.Args) || got.IsArgv() != test.want.IsArgv() {
+				t.Errorf("expand(%#v) = %#v, want %#v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTriggerEnviron(t *testing.T) {
+	trigger := Trigger{Reason: TriggerChange, ChangedFiles: []string{"a.go", "b c.go"}, RestartCount: 3}
+	want := []string{
+		"DUES_TRIGGER=change",
+		"DUES_CHANGED_FILES=a.go\nb c.go",
+		"DUES_RESTART_COUNT=3",
+	}
+	if got := trigger.environ(); !slices.Equal(got, want) {
+		t.Errorf("environ() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package process
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// startTTY starts cmd in a new session whose controlling terminal is a new
+// pseudo-terminal. Everything written to the terminal is copied to output and
+// stdin, if any, is copied to it. The size of the terminal follows the one
+// dues runs in. The returned function waits up to timeout for the output to
+// be drained and closes the terminal, it has to be called once cmd exited.
+// It reports false when output was still left after timeout
+func startTTY(cmd *exec.Cmd, stdin io.Reader, output io.Writer, timeout time.Duration) (func() bool, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+	inheritSize(ptmx)
+
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	// the session of the command is also its process group, so it can be
+	// stopped as a whole like any other command
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	if err := cmd.Start(); err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		// reading fails with EIO once every process using the terminal exited
+		io.Copy(output, ptmx)
+		close(drained)
+	}()
+	if stdin != nil {
+		go io.Copy(ptmx, stdin)
+	}
+
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	go func() {
+		for {
+			select {
+			case <-resized:
+				inheritSize(ptmx)
+			case <-drained:
+				return
+			}
+		}
+	}()
+
+	return func() bool {
+		defer signal.Stop(resized)
+		// closing the terminal ends the copy, as a process that is not part
+		// of the group of the command may keep it open for good
+		defer ptmx.Close()
+		return waitDrained(drained, timeout)
+	}, nil
+}
+
+// inheritSize gives ptmx the size of the terminal dues writes to, falling
+// back to 80x24 when dues does not run in one
+func inheritSize(ptmx *os.File) {
+	for _, terminal := range []*os.File{os.Stdout, os.Stdin} {
+		if size, err := pty.GetsizeFull(terminal); err == nil {
+			pty.Setsize(ptmx, size)
+			return
+		}
+	}
+	pty.Setsize(ptmx, &pty.Winsize{Cols: 80, Rows: 24})
+}
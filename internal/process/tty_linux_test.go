@@ -0,0 +1,32 @@
+package process
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunOutputHeldOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		tty  bool
+	}{
+		{"pipes", false},
+		{"pseudo-terminal", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Command{Name: "daemon", TTY: test.tty, StopTimeout: Duration(200 * time.Millisecond)}
+			started := time.Now()
+			// the process leaves the group of the command but keeps its output
+			_, err := c.runCmd([]string{"sh", "-c", "setsid sleep 3 & echo started"}, context.Background())
+			if err != nil {
+				t.Fatalf("runCmd returned error: %v", err)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("runCmd returned after %v, want it to stop waiting for the output after the stop timeout", elapsed)
+			}
+		})
+	}
+}
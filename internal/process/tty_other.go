@@ -0,0 +1,15 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// startTTY is only supported on Linux
+func startTTY(cmd *exec.Cmd, stdin io.Reader, output io.Writer, timeout time.Duration) (func() bool, error) {
+	return nil, errors.New("running commands in a pseudo-terminal is only supported on Linux")
+}
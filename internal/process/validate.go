@@ -0,0 +1,140 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/pattern"
+)
+
+// Validate checks that a processed command can actually be run: its cwd has
+// to be an existing directory, the executables of its command fields have to
+// resolve and its ignore and include patterns have to be valid. Every problem
+// found is returned.
+//
+// For scripts, the shell and the first executable the script runs are
+// checked. Scripts too complex to read without running them are not checked,
+// which is logged as a warning.
+func (c *Command) Validate() error {
+	var errs []error
+
+	if info, err := os.Stat(c.Cwd); err != nil {
+		errs = append(errs, errors.New(fmt.Sprintf("Command '%v' cwd '%v' does not exist", c.Name, c.Cwd)))
+	} else if !info.IsDir() {
+		errs = append(errs, errors.New(fmt.Sprintf("Command '%v' cwd '%v' is not a directory", c.Name, c.Cwd)))
+	}
+
+	type commandField struct {
+		field       string
+		commandLine CommandLine
+		// the executable may be built by one of the earlier steps
+		built bool
+	}
+	var commandFields []commandField
+	if len(c.Steps) == 0 {
+		commandFields = append(commandFields, commandField{field: "command", commandLine: c.mainStep()})
+	}
+	for i, step := range c.Steps {
+		commandFields = append(commandFields, commandField{field: fmt.Sprintf("steps[%d]", i), commandLine: step, built: i > 0})
+	}
+	commandFields = append(commandFields,
+		commandField{field: "preCommand", commandLine: c.PreCommand},
+		commandField{field: "postCommand", commandLine: c.PostCommand},
+	)
+	if c.Healthcheck != nil {
+		commandFields = append(commandFields, commandField{field: "healthcheck.exec", commandLine: c.Healthcheck.Exec})
+	}
+	for i, rule := range c.On {
+		commandFields = append(commandFields, commandField{field: fmt.Sprintf("on[%d].run", i), commandLine: rule.Run})
+	}
+
+	shellChecked := false
+	for _, commandField := range commandFields {
+		if commandField.commandLine.IsEmpty() {
+			continue
+		}
+
+		executable := ""
+		if commandField.commandLine.IsArgv() {
+			executable = commandField.commandLine.Args[0]
+		} else {
+			if !shellChecked {
+				shellChecked = true
+				if err := c.lookPath(ShellArgv(c.Shell)[0]); err != nil {
+					errs = append(errs, errors.New(fmt.Sprintf("Command '%v' field 'shell': %v", c.Name, err)))
+				}
+			}
+
+			if !isPOSIXShell(c.Shell) {
+				log.Logger.Warn(fmt.Sprintf("Command '%v' field '%v': the executable check was skipped, scripts are only read for POSIX shells", c.Name, commandField.field))
+				continue
+			}
+			var readable bool
+			executable, readable = scriptExecutable(commandField.commandLine.Script)
+			if !readable {
+				log.Logger.Warn(fmt.Sprintf("Command '%v' field '%v': the executable check was skipped, the script is too complex to tell which executable it runs", c.Name, commandField.field))
+				continue
+			}
+		}
+
+		if executable == "" || (commandField.built && strings.ContainsRune(executable, filepath.Separator)) {
+			continue
+		}
+		if err := c.lookPath(executable); err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("Command '%v' field '%v': %v", c.Name, commandField.field, err)))
+		}
+	}
+
+	type patternField struct {
+		field    string
+		patterns []string
+	}
+	patternFields := []patternField{
+		{"ignore", c.Ignore},
+		{"include", c.Include},
+	}
+	for _, patternField := range patternFields {
+		for i, p := range patternField.patterns {
+			if err := pattern.Validate(p); err != nil {
+				errs = append(errs, errors.New(fmt.Sprintf("Command '%v' field '%v[%d]': invalid pattern '%v': %v", c.Name, patternField.field, i, p, err)))
+			}
+		}
+	}
+
+	for _, envFile := range c.EnvFile {
+		if _, err := os.Stat(envFile); err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("Command '%v' env file '%v' does not exist", c.Name, envFile)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// lookPath checks that executable can be found. Executables containing a path
+// separator are resolved against the cwd, others are searched for on PATH
+func (c *Command) lookPath(executable string) error {
+	if !strings.ContainsRune(executable, filepath.Separator) {
+		_, err := exec.LookPath(executable)
+		if err != nil {
+			return fmt.Errorf("executable '%v' was not found on PATH", executable)
+		}
+		return nil
+	}
+
+	if !filepath.IsAbs(executable) {
+		executable = filepath.Join(c.Cwd, executable)
+	}
+	info, err := os.Stat(executable)
+	if err != nil {
+		return fmt.Errorf("executable '%v' does not exist", executable)
+	}
+	if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("'%v' is not an executable file", executable)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// handleChange reacts to the changes collected while the debouncer waited.
+// The rules of the command handle the files they match first. A main command
+// that is not running is then launched. A running one is sent its reload
+// signal when every remaining file asks for it, otherwise the onBusy field of
+// the command decides what happens to it
+func (dr *DuesCommandRunner) handleChange() {
+	files, restartRequested := dr.takeChanges()
+	if len(files) > 0 {
+		log.Logger.Info(dr.summarize(files))
+	}
+	affected, reload := dr.applyRules(files)
+	if len(files) > 0 && len(affected) == 0 && !restartRequested {
+		// the rules handled every file
+		return
+	}
+
+	trigger := dr.newTrigger(affected, restartRequested)
+	if !dr.isBusy() {
+		dr.launchMainCommand(trigger)
+		return
+	}
+
+	if !restartRequested && reload {
+		log.Logger.Info(fmt.Sprintf("Reloading command %v with %v", dr.command.Name, dr.command.ReloadSignal))
+		err := dr.command.SignalCommand(dr.command.ReloadSignal)
+		if err == nil {
+			return
+		}
+		log.Logger.Error(fmt.Sprintf("Could not reload command %v, restarting it instead: %v", dr.command.Name, err))
+		dr.launchMainCommand(trigger)
+		return
+	}
+
+	switch dr.command.OnBusy {
+	case process.OnBusyQueue:
+		dr.queue(trigger)
+		log.Logger.Info(fmt.Sprintf("Command %v is still running, it will run again once it exits", dr.command.Name))
+	case process.OnBusyDoNothing:
+		log.Logger.Info(fmt.Sprintf("Command %v is still running, ignoring the change", dr.command.Name))
+	case process.OnBusySignal:
+		log.Logger.Info(fmt.Sprintf("Command %v is still running, sending it %v", dr.command.Name, dr.command.ReloadSignal))
+		if err := dr.command.SignalCommand(dr.command.ReloadSignal); err != nil {
+			log.Logger.Error(fmt.Sprintf("Could not send %v to command %v: %v", dr.command.ReloadSignal, dr.command.Name, err))
+		}
+	default:
+		dr.launchMainCommand(trigger)
+	}
+}
+
+// newTrigger describes a run started for the changes collected while the
+// debouncer waited
+func (dr *DuesCommandRunner) newTrigger(files []string, restartRequested bool) process.Trigger {
+	trigger := process.Trigger{Reason: process.TriggerChange, ChangedFiles: files}
+	if dr.State() == StateIdle {
+		trigger.Reason = process.TriggerStart
+	} else if len(files) == 0 && restartRequested {
+		trigger.Reason = process.TriggerDependency
+	}
+	return trigger
+}
+
+// summarize describes the changed files in a single line, such as
+// "3 files of command api changed: a.go, b.go, c.go", with paths relative to
+// the cwd
+func (dr *DuesCommandRunner) summarize(files []string) string {
+	const shown = 5
+
+	list := strings.Join(dr.relative(files[:min(len(files), shown)]), ", ")
+	if len(files) > shown {
+		list += fmt.Sprintf(" and %d more", len(files)-shown)
+	}
+
+	if len(files) == 1 {
+		return fmt.Sprintf("1 file of command %v changed: %v", dr.command.Name, list)
+	}
+	return fmt.Sprintf("%d files of command %v changed: %v", len(files), dr.command.Name, list)
+}
+
+// relative returns files with paths relative to the cwd of the command
+// where possible
+func (dr *DuesCommandRunner) relative(files []string) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file
+		if relative, err := filepath.Rel(dr.command.Cwd, file); err == nil && !strings.HasPrefix(relative, "..") {
+			names[i] = relative
+		}
+	}
+	return names
+}
+
+// queue makes the main command run again with trigger once the running
+// instance exits. The files of a run that is already queued are kept
+func (dr *DuesCommandRunner) queue(trigger process.Trigger) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if dr.queued != nil {
+		for _, file := range trigger.ChangedFiles {
+			if !slices.Contains(dr.queued.ChangedFiles, file) {
+				dr.queued.ChangedFiles = append(dr.queued.ChangedFiles, file)
+			}
+		}
+		return
+	}
+	dr.queued = &trigger
+}
+
+// addChange records that path changed, or that a restart was asked for when
+// path is empty
+func (dr *DuesCommandRunner) addChange(path string) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if path == "" {
+		dr.restartRequested = true
+		return
+	}
+	if !slices.Contains(dr.changedFiles, path) {
+		dr.changedFiles = append(dr.changedFiles, path)
+	}
+}
+
+// takeChanges returns the changes recorded since it was last called. Env
+// file changes count as a restart request, as the environment of a running
+// command cannot be reloaded
+func (dr *DuesCommandRunner) takeChanges() ([]string, bool) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	files, restartRequested := dr.changedFiles, dr.restartRequested
+	dr.changedFiles = nil
+	dr.restartRequested = false
+	for _, file := range files {
+		restartRequested = restartRequested || dr.command.IsEnvFile(file)
+	}
+	return files, restartRequested
+}
+
+// isBusy reports whether an instance of the main command is running
+func (dr *DuesCommandRunner) isBusy() bool {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return dr.state == StateStarting || dr.state == StateRunning
+}
+
+// takeQueued returns the run that has to follow the run that closed done
+// because of changes made while it was running, if any, and clears it. Only
+// runs that exited on their own are followed by the queued run, a run stopped
+// by dues is being replaced already
+func (dr *DuesCommandRunner) takeQueued(done chan struct{}, exit process.Exit) *process.Trigger {
+	if exit.Stopped {
+		return nil
+	}
+
+	dr.lifecycle.Lock()
+	defer dr.lifecycle.Unlock()
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if dr.stopped || dr.mainDone != done {
+		return nil
+	}
+	queued := dr.queued
+	dr.queued = nil
+	return queued
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/probe"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// monitorHealth runs the healthcheck of the command every interval while the
+// run of the main command whose context is ctx is running. The run is
+// restarted by the supervisor once the healthcheck fails too many times in a
+// row
+func (dr *DuesCommandRunner) monitorHealth(ctx context.Context) {
+	healthcheck := dr.command.Healthcheck
+	if healthcheck == nil {
+		return
+	}
+
+	var check probe.Check
+	switch {
+	case healthcheck.HTTP != "":
+		check = probe.HTTP(healthcheck.HTTP)
+	case healthcheck.TCP != "":
+		check = probe.TCP(healthcheck.TCP)
+	default:
+		check = dr.command.RunHealthcheckCommand
+	}
+
+	ticker := time.NewTicker(healthcheck.Interval.Or(process.DefaultHealthcheckInterval))
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, healthcheck.Timeout.Or(process.DefaultHealthcheckTimeout))
+		err := check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			if failures > 0 {
+				log.Logger.Info(fmt.Sprintf("Command %v is healthy again", dr.command.Name))
+			}
+			failures = 0
+			continue
+		}
+
+		failures++
+		log.Logger.Error(fmt.Sprintf("Healthcheck of command %v failed (%d/%d), %v: %v", dr.command.Name, failures, healthcheck.Threshold(), healthcheck, err))
+		if failures >= healthcheck.Threshold() {
+			log.Logger.Error(fmt.Sprintf("Command %v is unhealthy after %d failed healthchecks in a row", dr.command.Name, failures))
+			dr.restartUnhealthy(ctx)
+			return
+		}
+	}
+}
+
+// restartUnhealthy asks the supervisor whether the unhealthy run of the main
+// command whose context is ctx is restarted, and when. Unhealthy runs count
+// towards the backoff and crash loop detection like crashes do
+func (dr *DuesCommandRunner) restartUnhealthy(ctx context.Context) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if dr.stopped || ctx.Err() != nil || dr.restart != nil {
+		// the runner shut down, the run was replaced or a restart is pending
+		return
+	}
+
+	delay, restart := dr.supervisor.unhealthy(time.Since(dr.runStarted))
+	if !restart {
+		log.Logger.Error(fmt.Sprintf("Leaving unhealthy command %v running", dr.command.Name))
+		return
+	}
+	log.Logger.Info(fmt.Sprintf("Restarting unhealthy command %v in %v (restart %d)", dr.command.Name, delay, dr.supervisor.restarts))
+	dr.scheduleRun(delay, process.Trigger{Reason: process.TriggerHealthcheck})
+}
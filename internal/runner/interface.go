@@ -1,10 +1,16 @@
 package runner
 
 import (
-	"os"
+	"context"
 	"sync"
 )
 
 type Runner interface {
-  CommandLoop(wg *sync.WaitGroup, sigs chan os.Signal) 
+	CommandLoop(wg *sync.WaitGroup, ctx context.Context)
+	Restart()
+	Ready() <-chan struct{}
+	ReadyErr() error
+	IsReady() bool
+	State() State
+	RestartCount() int
 }
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/probe"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// probeReadiness waits in the background for the run of the main command
+// whose context is ctx to become ready, according to the ready probe of the
+// command. It returns the writers the output of the run has to be copied to
+// for log probes, which are nil for every other probe. Once the run is ready
+// its healthcheck is monitored. Runs without a ready probe are ready once
+// their process started, see launched
+func (dr *DuesCommandRunner) probeReadiness(ctx context.Context) (stdout io.Writer, stderr io.Writer) {
+	readyProbe := dr.command.Ready
+	if readyProbe == nil {
+		return nil, nil
+	}
+
+	var check probe.Check
+	switch {
+	case readyProbe.TCP != "":
+		check = probe.TCP(readyProbe.TCP)
+	case readyProbe.HTTP != "":
+		check = probe.HTTP(readyProbe.HTTP)
+	case readyProbe.Log != "":
+		matcher, err := probe.NewLogMatcher(readyProbe.Log)
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("Invalid ready probe of command %v: %v", dr.command.Name, err))
+			dr.failReadyRun(ctx, fmt.Errorf("its ready probe is invalid: %w", err))
+			return nil, nil
+		}
+		check = matcher.Check
+		stdout, stderr = matcher.Writer(), matcher.Writer()
+	default:
+		check = probe.File(readyProbe.File)
+	}
+
+	go func() {
+		started := time.Now()
+		timeout := readyProbe.Timeout.Or(process.DefaultReadyTimeout)
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := probe.Poll(probeCtx, readyProbe.Interval.Or(process.DefaultProbeInterval), check)
+		if ctx.Err() != nil {
+			// the run was stopped before it became ready
+			return
+		}
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("Command %v did not become ready within %v, %v: %v", dr.command.Name, timeout, readyProbe, err))
+			dr.failReadyRun(ctx, fmt.Errorf("it did not become ready within %v", timeout))
+			return
+		}
+		log.Logger.Info(fmt.Sprintf("Command %v is ready after %v (%v)", dr.command.Name, time.Since(started).Round(time.Millisecond), readyProbe))
+		dr.markReady(ctx)
+		dr.monitorHealth(ctx)
+	}()
+	return stdout, stderr
+}
+
+// launched is called once the process of the run of the main command whose
+// context is ctx started. Without a ready probe the run is ready from then on
+func (dr *DuesCommandRunner) launched(ctx context.Context) {
+	if dr.command.Ready != nil {
+		return
+	}
+	dr.markReady(ctx)
+	go dr.monitorHealth(ctx)
+}
+
+// markReady records that the run of the main command whose context is ctx is
+// ready, unless it has been stopped in the meantime
+func (dr *DuesCommandRunner) markReady(ctx context.Context) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	dr.setState(StateRunning)
+	dr.readyOnce.Do(func() { close(dr.ready) })
+}
+
+// failReadyRun fails the commands waiting for the main command to be ready
+// because the run whose context is ctx will not become ready, unless the run
+// has been stopped in the meantime or the main command has been ready before
+func (dr *DuesCommandRunner) failReadyRun(ctx context.Context, err error) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	dr.failReady(err)
+}
+
+// IsReady reports whether the running instance of the main command is ready
+func (dr *DuesCommandRunner) IsReady() bool {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return dr.state == StateRunning
+}
@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// applyRules hands every changed file to the first rule of the command that
+// matches it and runs the run rules with the files they matched. It returns
+// the files that still affect the main command, which are the ones matched by
+// restart and reload rules and the ones no rule matched, and whether all of
+// them only ask for a reload. Run rules run in the background
+func (dr *DuesCommandRunner) applyRules(files []string) ([]string, bool) {
+	if len(dr.command.On) == 0 {
+		return files, dr.command.ReloadsOn(files)
+	}
+
+	matched := make(map[int][]string)
+	var affected []string
+	reload := true
+	for _, file := range files {
+		i := dr.command.RuleFor(file)
+		if i < 0 {
+			affected = append(affected, file)
+			reload = reload && dr.command.ReloadsOn([]string{file})
+			continue
+		}
+
+		matched[i] = append(matched[i], file)
+		switch dr.command.On[i].Action {
+		case process.RuleRestart:
+			affected = append(affected, file)
+			reload = false
+		case process.RuleReload:
+			affected = append(affected, file)
+		}
+	}
+
+	for i, rule := range dr.command.On {
+		if len(matched[i]) == 0 {
+			continue
+		}
+		log.Logger.Info(fmt.Sprintf("Rule %d of command %v (%v) matched %v", i, dr.command.Name, rule, strings.Join(dr.relative(matched[i]), ", ")))
+		if rule.Action == process.RuleRun {
+			dr.runRule(i, rule, matched[i])
+		}
+	}
+	return affected, reload && len(affected) > 0
+}
+
+// ruleRun is a run of the command line of a run rule
+type ruleRun struct {
+	cancel context.CancelFunc
+	// closed once the run has exited
+	done chan struct{}
+}
+
+// runRule runs the command line of the run rule at index i of the command
+// for the files it matched in the background, leaving the main command alone.
+// A run of the same rule that is still going is stopped first
+func (dr *DuesCommandRunner) runRule(i int, rule process.Rule, files []string) {
+	dr.mutex.Lock()
+	if dr.stopped || dr.ctx.Err() != nil {
+		dr.mutex.Unlock()
+		return
+	}
+	if dr.ruleRuns == nil {
+		dr.ruleRuns = make(map[int]*ruleRun)
+	}
+	previous := dr.ruleRuns[i]
+	ctx, cancel := context.WithCancel(dr.ctx)
+	run := &ruleRun{cancel: cancel, done: make(chan struct{})}
+	dr.ruleRuns[i] = run
+	trigger := process.Trigger{Reason: process.TriggerChange, ChangedFiles: files, RestartCount: max(dr.launches-1, 0)}
+	dr.mutex.Unlock()
+
+	if previous != nil {
+		select {
+		case <-previous.done:
+		default:
+			log.Logger.Info(fmt.Sprintf("Rule %d of command %v is still running, stopping it", i, dr.command.Name))
+			previous.cancel()
+		}
+	}
+
+	go func() {
+		defer close(run.done)
+		defer func() {
+			cancel()
+			dr.mutex.Lock()
+			if dr.ruleRuns[i] == run {
+				delete(dr.ruleRuns, i)
+			}
+			dr.mutex.Unlock()
+		}()
+
+		if previous != nil {
+			<-previous.done
+		}
+		if ctx.Err() != nil {
+			// replaced by a newer run or stopped along with the runner
+			return
+		}
+
+		exit, err := dr.command.LaunchRule(ctx, rule, trigger)
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("An error occured launching rule %d of command %v: %v", i, dr.command.Name, err))
+			return
+		}
+		dr.reportExit(fmt.Sprintf("on[%d]", i), exit)
+	}()
+}
+
+// stopRuleRuns stops the runs of the run rules and waits for them to exit
+func (dr *DuesCommandRunner) stopRuleRuns() {
+	dr.mutex.Lock()
+	runs := make([]*ruleRun, 0, len(dr.ruleRuns))
+	for _, run := range dr.ruleRuns {
+		runs = append(runs, run)
+	}
+	dr.mutex.Unlock()
+
+	for _, run := range runs {
+		run.cancel()
+		<-run.done
+	}
+}
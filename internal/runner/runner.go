@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/anjolaoluwaakindipe/dues/internal/debounce"
 	"github.com/anjolaoluwaakindipe/dues/internal/filewatcher"
+	"github.com/anjolaoluwaakindipe/dues/internal/input"
 	"github.com/anjolaoluwaakindipe/dues/internal/log"
 	"github.com/anjolaoluwaakindipe/dues/internal/pattern"
 	"github.com/anjolaoluwaakindipe/dues/internal/process"
@@ -17,9 +21,63 @@ import (
 )
 
 type DuesCommandRunner struct {
-	debouncer *debounce.Debouncer
-	command   *process.Command
-	watcher   filewatcher.Watcher
+	debouncer    *debounce.Debouncer
+	command      *process.Command
+	watcher      filewatcher.Watcher
+	ignoredFiles []string
+	// guards the fields below, it is never held while waiting for a process
+	mutex sync.Mutex
+	// serializes the transitions of the lifecycle, so that an instance of the
+	// main command is only launched once the previous one has exited
+	lifecycle  sync.Mutex
+	state      State
+	cancelMain context.CancelFunc
+	// closed once the running instance of the main command has exited
+	mainDone chan struct{}
+	// set once the runner shuts down, after which the main command is never
+	// launched again
+	stopped bool
+	// called with how every run of the command fields ended
+	onExit func(command *process.Command, field string, exit process.Exit)
+	// restarts the main command when it exits by itself
+	supervisor *supervisor
+	// pending restart scheduled by the supervisor
+	restart *pendingRestart
+	// when the running instance of the main command was started
+	runStarted time.Time
+	// cancels the build steps that are running, buildDone is closed once
+	// they have stopped
+	cancelBuild context.CancelFunc
+	buildDone   chan struct{}
+	// closed once the main command has been ready for the first time, or
+	// once readyErr tells why it never will be
+	ready     chan struct{}
+	readyOnce sync.Once
+	readyErr  error
+	// how many times the main command has been started
+	launches int
+	// run of the main command following the running instance once it exits,
+	// set by changes made while it was busy
+	queued *process.Trigger
+	// files changed since the debouncer started, and whether a restart was
+	// asked for in the meantime regardless of the files
+	changedFiles     []string
+	restartRequested bool
+	// runners of the commands this one depends on, keyed by name
+	dependencies map[string]Runner
+	// called whenever the main command is started again after its first run
+	onRestart func(*process.Command)
+	// forwards terminal input to commands with stdin set
+	input *input.Router
+	// context of the CommandLoop, the runs of rules are stopped along with it
+	ctx context.Context
+	// running runs of the run rules, keyed by the index of their rule
+	ruleRuns map[int]*ruleRun
+}
+
+// pendingRestart is a restart of the main command waiting for its backoff
+type pendingRestart struct {
+	timer *time.Timer
 }
 
 // addFilesToWatcher includes paths the a filewatcher.Watcher
@@ -29,43 +87,477 @@ func (dr *DuesCommandRunner) addFilesToWatcher(path string) {
 
 // cleanup cleans up the the CommandLoop
 func (dr *DuesCommandRunner) cleanUp(wg *sync.WaitGroup) {
+	if dr.command.Stdin && dr.input != nil {
+		dr.input.Unregister(dr.command.Name)
+	}
 	dr.debouncer.Cancel()
 	dr.watcher.Close()
 	wg.Done()
 }
 
 // startMainCommand starts the command field of the process.Command given inside the
-// Debouncer
-func (dr *DuesCommandRunner) startMainCommand(cancellation *context.CancelFunc) {
+// Debouncer. A running instance of the command is stopped before the new one is
+// launched
+func (dr *DuesCommandRunner) startMainCommand() {
+	dr.debouncer.StartAsync(dr.command.Debounce.Or(100*time.Millisecond), dr.handleChange)
+}
+
+// launchMainCommand starts the main command because files changed or the
+// runner just started, which resets the restart backoff. Commands with steps
+// are built first and the running instance is only replaced when every build
+// step succeeds
+func (dr *DuesCommandRunner) launchMainCommand(trigger process.Trigger) {
+	build, succeeded := dr.runBuildSteps(trigger)
+	if !succeeded {
+		dr.mutex.Lock()
+		if dr.launches == 0 && !dr.stopped && build != nil && build.Err() == nil {
+			// the dependents are waiting for a first run that never comes
+			dr.failReady(errors.New("its first build failed"))
+		}
+		dr.mutex.Unlock()
+		return
+	}
+	dr.runMainCommand(nil, build, trigger)
+}
+
+// runBuildSteps runs every step of the command but the last one while the
+// running instance keeps running. A build that is still running is cancelled
+// first. It returns the context of the build, which is cancelled once a newer
+// build replaces it, and reports whether every step succeeded
+func (dr *DuesCommandRunner) runBuildSteps(trigger process.Trigger) (context.Context, bool) {
+	steps := dr.command.BuildSteps()
+	if len(steps) == 0 {
+		return nil, true
+	}
+
+	dr.lifecycle.Lock()
+	dr.stopRunningBuild()
+	dr.mutex.Lock()
+	if dr.stopped {
+		// the runner shut down, possibly while the previous build was
+		// stopping
+		dr.mutex.Unlock()
+		dr.lifecycle.Unlock()
+		return nil, false
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	dr.debouncer.Start(100*time.Millisecond, func() {
-		if cancellation != nil {
-			(*cancellation)()
-			cancellation = &cancel
+	done := make(chan struct{})
+	dr.cancelBuild = cancel
+	dr.buildDone = done
+	// the steps are part of the run that follows them
+	trigger.RestartCount = dr.launches
+	dr.mutex.Unlock()
+	dr.lifecycle.Unlock()
+	defer close(done)
+
+	for i, step := range steps {
+		log.Logger.Info(fmt.Sprintf("Running step %d/%d of command %v: %v", i+1, len(dr.command.Steps), dr.command.Name, step))
+		exit, err := dr.command.LaunchStep(ctx, step, trigger)
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("An error occured launching step %d of command %v: %v", i+1, dr.command.Name, err))
+			return ctx, false
 		}
-		if err := dr.command.LaunchCommand(ctx); err != nil {
-			log.Logger.Error(fmt.Sprintf("An error occured launching command field: %v", err))
+		if exit.Stopped {
+			log.Logger.Info(fmt.Sprintf("Step %d of command %v was cancelled", i+1, dr.command.Name))
+			return ctx, false
+		}
+		dr.reportExit(fmt.Sprintf("step %d", i+1), exit)
+		if exit.Failed() {
+			if dr.hasLaunched() {
+				log.Logger.Error(fmt.Sprintf("Build of command %v failed, keeping the previous instance running", dr.command.Name))
+			} else {
+				log.Logger.Error(fmt.Sprintf("Build of command %v failed", dr.command.Name))
+			}
+			return ctx, false
 		}
+	}
+	return ctx, true
+}
+
+// stopRunningBuild cancels the running build steps and waits for them to
+// exit. The lifecycle mutex must be held by the caller
+func (dr *DuesCommandRunner) stopRunningBuild() {
+	dr.mutex.Lock()
+	cancel, done := dr.cancelBuild, dr.buildDone
+	dr.cancelBuild = nil
+	dr.buildDone = nil
+	dr.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// runMainCommand stops the running instance of the main command, waits for
+// it to exit and runs a new one until it exits or is stopped. Once it exits
+// by itself it is restarted according to its restart policy. restart is the
+// restart of the supervisor that triggered the run, nil for any other run, and
+// cause is the context of what caused the run, such as the build steps that
+// preceded it, if any. The run is skipped once cause is cancelled. trigger is
+// handed to the new run
+func (dr *DuesCommandRunner) runMainCommand(restart *pendingRestart, cause context.Context, trigger process.Trigger) {
+	dr.lifecycle.Lock()
+	dr.mutex.Lock()
+	if dr.stopped || (restart != nil && restart != dr.restart) || (cause != nil && cause.Err() != nil) {
+		// the runner shut down, the restart was cancelled after its timer fired
+		// or what triggered the run was replaced in the meantime
+		dr.mutex.Unlock()
+		dr.lifecycle.Unlock()
+		return
+	}
+	dr.cancelRestart()
+	if restart == nil {
+		dr.supervisor.reset()
+	}
+	dr.mutex.Unlock()
+
+	dr.stopRunningMain()
+
+	dr.mutex.Lock()
+	if dr.stopped {
+		// the runner shut down while the previous instance was stopping
+		dr.mutex.Unlock()
+		dr.lifecycle.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	dr.cancelMain = cancel
+	dr.mainDone = done
+	dr.launches++
+	dr.runStarted = time.Now()
+	restarted := dr.launches > 1
+	trigger.RestartCount = dr.launches - 1
+	dr.queued = nil
+	dr.setState(StateStarting)
+	dr.mutex.Unlock()
+	// the next transition stops this run through its context before launching
+	// another one, so the lifecycle is not held for the whole run
+	dr.lifecycle.Unlock()
+
+	if restarted {
+		log.Logger.Info(fmt.Sprintf("Starting command %v again because of a %v (restart count %d)", dr.command.Name, trigger.Reason, trigger.RestartCount))
+		if dr.onRestart != nil {
+			dr.onRestart(dr.command)
+		}
+	}
+	if ctx.Err() != nil {
+		// the runner shut down or a newer run replaced this one before it
+		// was launched
+		dr.endRun(cancel, done)
+		return
+	}
+	var streams process.Streams
+	streams.Stdout, streams.Stderr = dr.probeReadiness(ctx)
+	stdin, closeStdin := dr.openStdin()
+	streams.Stdin = stdin
+	exit, err := dr.command.LaunchCommand(ctx, streams, trigger, func() { dr.launched(ctx) })
+	closeStdin()
+	dr.endRun(cancel, done)
+	if err != nil {
+		log.Logger.Error(fmt.Sprintf("An error occured launching command field: %v", err))
+		dr.mutex.Lock()
+		if !dr.stopped {
+			dr.failReady(fmt.Errorf("it could not be launched: %w", err))
+		}
+		dr.mutex.Unlock()
+		return
+	}
+	dr.reportExit("command", exit)
+	if queued := dr.takeQueued(done, exit); queued != nil {
+		log.Logger.Info(fmt.Sprintf("Running command %v again for the changes made while it was running", dr.command.Name))
+		dr.launchMainCommand(*queued)
+		return
+	}
+	dr.scheduleRestart(done, exit)
+}
+
+// endRun marks the run of the main command whose context is cancelled by
+// cancel as exited and closes done
+func (dr *DuesCommandRunner) endRun(cancel context.CancelFunc, done chan struct{}) {
+	// ends the probes and healthchecks of the run
+	cancel()
+	dr.mutex.Lock()
+	if dr.mainDone == done {
+		dr.setState(StateExited)
+	}
+	dr.mutex.Unlock()
+	close(done)
+}
+
+// scheduleRestart asks the supervisor whether the run that closed done is
+// restarted, and when
+func (dr *DuesCommandRunner) scheduleRestart(done chan struct{}, exit process.Exit) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	if dr.stopped || dr.mainDone != done || dr.restart != nil {
+		// the runner shut down, a newer run already replaced this one or a
+		// restart is already pending because the run was unhealthy
+		return
+	}
+
+	delay, restart := dr.supervisor.next(exit)
+	if !restart {
+		// the commands waiting for the main command to be ready would wait
+		// for good otherwise
+		dr.failReady(fmt.Errorf("it %v before it was ready", exit))
+		return
+	}
+	log.Logger.Info(fmt.Sprintf("Restarting command %v in %v (restart %d)", dr.command.Name, delay, dr.supervisor.restarts))
+	dr.scheduleRun(delay, process.Trigger{Reason: process.TriggerRestart})
+}
+
+// scheduleRun runs the main command with trigger once delay has passed,
+// unless the pending restart is cancelled in the meantime. The mutex must be
+// held by the caller
+func (dr *DuesCommandRunner) scheduleRun(delay time.Duration, trigger process.Trigger) {
+	pending := &pendingRestart{}
+	pending.timer = time.AfterFunc(delay, func() {
+		dr.runMainCommand(pending, nil, trigger)
+	})
+	dr.restart = pending
+}
+
+// openStdin creates the pipe a run of the main command reads its input from
+// and attaches it to the input router. The returned function closes the pipe
+// once the run exited
+func (dr *DuesCommandRunner) openStdin() (io.Reader, func()) {
+	if !dr.command.Stdin || dr.input == nil {
+		return nil, func() {}
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		log.Logger.Error(fmt.Sprintf("Could not connect the input of command %v: %v", dr.command.Name, err))
+		return nil, func() {}
+	}
+	dr.input.Attach(dr.command.Name, writer)
+	return reader, func() {
+		dr.input.Detach(dr.command.Name, writer)
+		writer.Close()
+		reader.Close()
+	}
+}
+
+// cancelRestart cancels the pending restart of the main command, if any. The
+// mutex must be held by the caller
+func (dr *DuesCommandRunner) cancelRestart() {
+	if dr.restart != nil {
+		dr.restart.timer.Stop()
+		dr.restart = nil
+	}
+}
+
+// reportExit logs how a run of field ended and hands it to the exit handler.
+// Runs stopped by dues were already logged while stopping them
+func (dr *DuesCommandRunner) reportExit(field string, exit process.Exit) {
+	message := fmt.Sprintf("Command %v %v", dr.command.Name, exit)
+	if field != "command" {
+		message = fmt.Sprintf("Command %v %v %v", dr.command.Name, field, exit)
+	}
+
+	if exit.Failed() {
+		log.Logger.Error(message)
+	} else if !exit.Stopped {
+		log.Logger.Info(message)
+	}
+
+	if dr.onExit != nil {
+		dr.onExit(dr.command, field, exit)
+	}
+}
+
+// restartMainCommand handles a change of path once the debouncer settles,
+// which restarts the main command unless its reload or onBusy fields say
+// otherwise. An empty path asks for a restart without any file changing
+func (dr *DuesCommandRunner) restartMainCommand(path string) {
+	dr.addChange(path)
+	eventDelay := dr.command.Debounce.Or(1 * time.Second)
+	hasReset := dr.debouncer.Reset(&eventDelay)
+	if !hasReset {
+		dr.startMainCommand()
+	}
+}
+
+// stopRunningMain cancels the context of the running main command and waits
+// for its process group to exit. The lifecycle mutex must be held by the
+// caller
+func (dr *DuesCommandRunner) stopRunningMain() {
+	dr.mutex.Lock()
+	cancel, done := dr.cancelMain, dr.mainDone
+	if cancel == nil {
+		dr.mutex.Unlock()
+		return
+	}
+	if dr.state != StateExited {
+		dr.setState(StateStopping)
+	}
+	cancel()
+	dr.mutex.Unlock()
+
+	<-done
+
+	dr.mutex.Lock()
+	dr.cancelMain = nil
+	dr.mainDone = nil
+	dr.mutex.Unlock()
+}
+
+// stopMainCommand stops the running main command and the runs of its rules
+// for good
+func (dr *DuesCommandRunner) stopMainCommand() {
+	// set before waiting for the lifecycle, so that a change or restart that
+	// is already waiting for it does not launch the main command again
+	dr.mutex.Lock()
+	dr.stopped = true
+	dr.cancelRestart()
+	dr.mutex.Unlock()
+
+	dr.lifecycle.Lock()
+	defer dr.lifecycle.Unlock()
+
+	dr.stopRunningBuild()
+	dr.stopRunningMain()
+	dr.stopRuleRuns()
+}
+
+// hasLaunched reports whether the main command has been started before
+func (dr *DuesCommandRunner) hasLaunched() bool {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return dr.launches > 0
+}
+
+// Restart restarts the main command once the debouncer settles, as if one of
+// its files had changed. Runners that have not started their main command yet
+// are left alone
+func (dr *DuesCommandRunner) Restart() {
+	dr.mutex.Lock()
+	started := dr.launches > 0 && !dr.stopped
+	dr.mutex.Unlock()
+
+	if started {
+		dr.restartMainCommand("")
+	}
+}
+
+// Ready returns a channel that is closed once the main command has been
+// ready for the first time, as told by its ready probe, or once ReadyErr
+// tells why it never will be
+func (dr *DuesCommandRunner) Ready() <-chan struct{} {
+	return dr.ready
+}
+
+// ReadyErr returns why the main command never became ready once the channel
+// returned by Ready is closed, and nil when it did
+func (dr *DuesCommandRunner) ReadyErr() error {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return dr.readyErr
+}
+
+// failReady releases the commands waiting for the main command to be ready
+// with err, unless it has been ready before. The mutex must be held by the
+// caller
+func (dr *DuesCommandRunner) failReady(err error) {
+	dr.readyOnce.Do(func() {
+		dr.readyErr = err
+		close(dr.ready)
 	})
 }
 
+// waitForDependencies blocks until every command the runner's command depends
+// on is ready. It fails when one of them never becomes ready, and returns the
+// error of ctx when ctx is cancelled first
+func (dr *DuesCommandRunner) waitForDependencies(ctx context.Context) error {
+	for _, name := range dr.command.DependsOn {
+		dependency, exists := dr.dependencies[name]
+		if !exists {
+			continue
+		}
+
+		select {
+		case <-dependency.Ready():
+		default:
+			log.Logger.Info(fmt.Sprintf("Command %v is waiting for %v to be ready", dr.command.Name, name))
+			select {
+			case <-dependency.Ready():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := dependency.ReadyErr(); err != nil {
+			return &dependencyError{name: name, err: err}
+		}
+	}
+	return nil
+}
+
+// dependencyError tells that a command the runner's command depends on never
+// became ready
+type dependencyError struct {
+	name string
+	err  error
+}
+
+func (de *dependencyError) Error() string {
+	return fmt.Sprintf("its dependency %v did not become ready: %v", de.name, de.err)
+}
+
+func (de *dependencyError) Unwrap() error {
+	return de.err
+}
+
+// isIgnoredFile checks whether path is one of the files the runner was told
+// to never react to
+func (dr *DuesCommandRunner) isIgnoredFile(path string) bool {
+	return slices.Contains(dr.ignoredFiles, filepath.Clean(path))
+}
+
 // CommandLoop is the main loop that watches and manages file events, executes all commands in a
 // process.Command, and handles debouncing on file changes
-func (dr *DuesCommandRunner) CommandLoop(wg *sync.WaitGroup, sigs chan os.Signal, ctx context.Context) {
+// until ctx is cancelled
+func (dr *DuesCommandRunner) CommandLoop(wg *sync.WaitGroup, ctx context.Context) {
 	defer dr.cleanUp(wg)
+	dr.ctx = ctx
+	if err := dr.waitForDependencies(ctx); err != nil {
+		var dependencyErr *dependencyError
+		if errors.As(err, &dependencyErr) {
+			log.Logger.Error(fmt.Sprintf("Command %v is not started, %v", dr.command.Name, dependencyErr))
+			// commands depending on this one fail in turn
+			dr.mutex.Lock()
+			dr.failReady(fmt.Errorf("its dependency %v did not become ready", dependencyErr.name))
+			dr.mutex.Unlock()
+		}
+		return
+	}
+	if dr.command.Stdin && dr.input != nil {
+		dr.input.Register(dr.command.Name)
+	}
 	utils.WalkSubdirectories(dr.command.Cwd, dr.addFilesToWatcher)
+	for _, envFile := range dr.command.EnvFile {
+		dr.addFilesToWatcher(filepath.Dir(envFile))
+	}
 
-	var cancellation *context.CancelFunc
-
-	preCtx, done := context.WithTimeout(context.Background(), 15*time.Second)
-	err := dr.command.LaunchPreCommand(preCtx)
+	if !dr.command.PreCommand.IsEmpty() {
+		preCtx, done := context.WithTimeout(context.Background(), 15*time.Second)
+		exit, err := dr.command.LaunchPreCommand(preCtx)
 
-	if err != nil {
-		log.Logger.Error(fmt.Sprintf("An error occured launching pre-command field: %v", err))
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("An error occured launching pre-command field: %v", err))
+		} else {
+			dr.reportExit("preCommand", exit)
+		}
+		done()
 	}
-	done()
 
-	go dr.startMainCommand(cancellation)
+	dr.startMainCommand()
 	eventChannel := dr.watcher.Events()
 
 	for {
@@ -73,6 +565,22 @@ func (dr *DuesCommandRunner) CommandLoop(wg *sync.WaitGroup, sigs chan os.Signal
 		case event, ok := <-eventChannel:
 			if !ok {
 				log.Logger.Error(fmt.Sprintf("An error occured while watching files belonging to command %s", dr.command.Name))
+				dr.stopMainCommand()
+				return
+			}
+			if dr.isIgnoredFile(event.Name()) {
+				continue
+			}
+
+			if dr.command.IsEnvFile(event.Name()) {
+				// env files are watched through their directory, so any kind of
+				// event can mean the file was saved
+				log.Logger.Info(fmt.Sprintf("Env file %v of command %s changed", event.Name(), dr.command.Name))
+				dr.restartMainCommand(event.Name())
+				continue
+			}
+			if !utils.IsSubPath(dr.command.Cwd, event.Name()) {
+				continue
 			}
 
 			if event.Has(filewatcher.Write) {
@@ -81,11 +589,7 @@ func (dr *DuesCommandRunner) CommandLoop(wg *sync.WaitGroup, sigs chan os.Signal
 				if pattern.Match(event.Name(), dr.command.Ignore) && !pattern.Match(event.Name(), dr.command.Include) {
 					continue
 				}
-				eventDelay := 1 * time.Second
-				hasReset := dr.debouncer.Reset(&eventDelay)
-				if !hasReset {
-					go dr.startMainCommand(cancellation)
-				}
+				dr.restartMainCommand(event.Name())
 			}
 			if event.Has(filewatcher.Create) {
 				// We assume that files would already been watched by a
@@ -102,18 +606,21 @@ func (dr *DuesCommandRunner) CommandLoop(wg *sync.WaitGroup, sigs chan os.Signal
 				return
 			}
 			log.Logger.Error(fmt.Sprintf("An error occured while wathcing files: %v", err))
-    case <-ctx.Done():
-		case <-sigs:
-			// Waits for interrupt signal from the os and begins cancellation and clean up process
-			if cancellation != nil {
-				(*cancellation)()
-			}
-			postCtx, done := context.WithTimeout(context.Background(), 15*time.Second)
-			err := dr.command.LaunchPostCommand(postCtx)
-			if err != nil {
-				log.Logger.Error(fmt.Sprintf("An error occured launching post command field: %v", err))
+		case <-ctx.Done():
+			// Begins cancellation and clean up process once dues stops or the
+			// command is removed from the config
+			dr.debouncer.Cancel()
+			dr.stopMainCommand()
+			if !dr.command.PostCommand.IsEmpty() {
+				postCtx, done := context.WithTimeout(context.Background(), 15*time.Second)
+				exit, err := dr.command.LaunchPostCommand(postCtx)
+				if err != nil {
+					log.Logger.Error(fmt.Sprintf("An error occured launching post command field: %v", err))
+				} else {
+					dr.reportExit("postCommand", exit)
+				}
+				done()
 			}
-			done()
 			return
 		}
 	}
@@ -129,17 +636,60 @@ func WithWatcher(w filewatcher.Watcher) DuesRunnerOptions {
 		dr.watcher = w
 	}
 }
+
+// WithIgnoredFiles sets files whose changes never restart the command, such as
+// the config file which dues reloads by itself
+func WithIgnoredFiles(paths ...string) DuesRunnerOptions {
+	return func(dr *DuesCommandRunner) {
+		for _, path := range paths {
+			dr.ignoredFiles = append(dr.ignoredFiles, filepath.Clean(path))
+		}
+	}
+}
 func WithCommand(c *process.Command) DuesRunnerOptions {
 	return func(dr *DuesCommandRunner) {
 		dr.command = c
 	}
 }
 
+// WithExitHandler sets a function called with how every run of the command
+// fields ended, including runs stopped by the runner itself
+func WithExitHandler(handler func(command *process.Command, field string, exit process.Exit)) DuesRunnerOptions {
+	return func(dr *DuesCommandRunner) {
+		dr.onExit = handler
+	}
+}
+
+// WithDependencies sets the runners of the commands the command depends on,
+// keyed by their name. The runner waits for them to be ready before starting
+func WithDependencies(dependencies map[string]Runner) DuesRunnerOptions {
+	return func(dr *DuesCommandRunner) {
+		dr.dependencies = dependencies
+	}
+}
+
+// WithRestartHandler sets a function called whenever the main command is
+// started again after its first run
+func WithRestartHandler(handler func(*process.Command)) DuesRunnerOptions {
+	return func(dr *DuesCommandRunner) {
+		dr.onRestart = handler
+	}
+}
+
+// WithInput sets the router forwarding terminal input to the command when it
+// has stdin set
+func WithInput(router *input.Router) DuesRunnerOptions {
+	return func(dr *DuesCommandRunner) {
+		dr.input = router
+	}
+}
+
 type DuesRunnerOptions func(*DuesCommandRunner)
 
 func NewDuesCommandRunner(options ...DuesRunnerOptions) (*DuesCommandRunner, error) {
 	runner := DuesCommandRunner{
 		debouncer: debounce.NewDebouncer(),
+		ready:     make(chan struct{}),
 	}
 
 	for _, opt := range options {
@@ -153,6 +703,7 @@ func NewDuesCommandRunner(options ...DuesRunnerOptions) (*DuesCommandRunner, err
 	if runner.command == nil {
 		return nil, errors.New("no process.Command was provided")
 	}
+	runner.supervisor = &supervisor{command: runner.command}
 
 	return &runner, nil
 }
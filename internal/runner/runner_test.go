@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/filewatcher"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// fakeWatcher is a filewatcher.Watcher that never reports any event
+type fakeWatcher struct {
+	events chan filewatcher.Event
+	errors chan error
+}
+
+func (fw *fakeWatcher) Add(path string) error          { return nil }
+func (fw *fakeWatcher) Close() error                   { return nil }
+func (fw *fakeWatcher) Remove(path string) error       { return nil }
+func (fw *fakeWatcher) Events() chan filewatcher.Event { return fw.events }
+func (fw *fakeWatcher) Errors() chan error             { return fw.errors }
+
+// newSlowStoppingRunner returns a runner whose main command ignores SIGTERM,
+// so stopping it takes the whole stop timeout
+func newSlowStoppingRunner(t *testing.T) *DuesCommandRunner {
+	t.Helper()
+	command := &process.Command{
+		Name:        "slow",
+		Command:     process.CommandLine{Script: "trap '' TERM; sleep 30"},
+		StopTimeout: process.Duration(300 * time.Millisecond),
+	}
+	dr, err := NewDuesCommandRunner(WithCommand(command), WithWatcher(&fakeWatcher{}))
+	if err != nil {
+		t.Fatalf("NewDuesCommandRunner returned error: %v", err)
+	}
+	return dr
+}
+
+// waitForState waits until the runner is in state, failing the test when it
+// does not get there in time
+func waitForState(t *testing.T, dr *DuesCommandRunner, state State) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for dr.State() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("runner is %v, want %v", dr.State(), state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopWhileChanging(t *testing.T) {
+	tests := []struct {
+		name string
+		// whether the change is fired before the stop begins, in which case
+		// it is stopping the previous instance when the stop comes in
+		changeFirst bool
+	}{
+		{"change during stop", false},
+		{"stop during change", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dr := newSlowStoppingRunner(t)
+			firstRun := make(chan struct{})
+			go func() {
+				dr.launchMainCommand(process.Trigger{Reason: process.TriggerStart})
+				close(firstRun)
+			}()
+			waitForState(t, dr, StateRunning)
+
+			changed := make(chan struct{})
+			change := func() {
+				dr.launchMainCommand(process.Trigger{Reason: process.TriggerChange})
+				close(changed)
+			}
+			stopped := make(chan struct{})
+			stop := func() {
+				dr.stopMainCommand()
+				close(stopped)
+			}
+
+			if test.changeFirst {
+				go change()
+				waitForState(t, dr, StateStopping)
+				go stop()
+			} else {
+				go stop()
+				waitForState(t, dr, StateStopping)
+				go change()
+			}
+			<-stopped
+			<-changed
+			<-firstRun
+
+			if launches := dr.RestartCount() + 1; launches != 1 {
+				t.Errorf("main command was launched %d times, want it launched only once", launches)
+			}
+			if state := dr.State(); state != StateExited {
+				t.Errorf("runner is %v after it stopped, want %v", state, StateExited)
+			}
+		})
+	}
+}
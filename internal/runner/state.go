@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+)
+
+// State is the stage of the lifecycle the main command of a runner is in
+type State int
+
+const (
+	// The main command has not been started yet
+	StateIdle State = iota
+	// An instance of the main command was launched and is not ready yet
+	StateStarting
+	// The running instance of the main command is ready
+	StateRunning
+	// The running instance of the main command is being stopped
+	StateStopping
+	// The last instance of the main command exited and none is running
+	StateExited
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateStopping:
+		return "stopping"
+	case StateExited:
+		return "exited"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// setState moves the main command to state. The mutex must be held by the
+// caller
+func (dr *DuesCommandRunner) setState(state State) {
+	if dr.state == state {
+		return
+	}
+	log.Logger.Debug(fmt.Sprintf("Command %v went from %v to %v", dr.command.Name, dr.state, state))
+	dr.state = state
+}
+
+// State returns the stage of the lifecycle the main command is in
+func (dr *DuesCommandRunner) State() State {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return dr.state
+}
+
+// RestartCount returns how many times the main command was started again
+// after its first run, whatever restarted it
+func (dr *DuesCommandRunner) RestartCount() int {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	return max(dr.launches-1, 0)
+}
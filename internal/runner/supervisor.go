@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// supervisor decides whether and when the main command is restarted after it
+// exits by itself, following the restart policy of the command
+type supervisor struct {
+	command *process.Command
+	// consecutive restarts since the last file change or healthy run
+	restarts int
+	// when the crashes within the crash loop window happened
+	crashes []time.Time
+}
+
+// reset forgets about previous restarts and crashes, which happens whenever
+// the command is started because of a file change
+func (s *supervisor) reset() {
+	s.restarts = 0
+	s.crashes = nil
+}
+
+// next returns the delay after which the command is restarted following a
+// run that ended with exit. It reports false when the command should stay
+// stopped
+func (s *supervisor) next(exit process.Exit) (time.Duration, bool) {
+	if !s.command.Restart.ShouldRestart(exit) {
+		return 0, false
+	}
+	return s.backoff(exit.Failed(), exit.Duration)
+}
+
+// unhealthy returns the delay after which the command is restarted because
+// its healthcheck kept failing after it ran for ran. Unhealthy runs count as
+// crashes regardless of the restart policy. It reports false when the command
+// should be left running
+func (s *supervisor) unhealthy(ran time.Duration) (time.Duration, bool) {
+	return s.backoff(true, ran)
+}
+
+// backoff returns the delay before the next restart of a run that lasted
+// ran, counting it as a crash when failed. It reports false once the command
+// is crash looping or was restarted too many times in a row
+func (s *supervisor) backoff(failed bool, ran time.Duration) (time.Duration, bool) {
+	command := s.command
+	window := command.CrashLoopWindow.Or(process.DefaultCrashLoopWindow)
+	if ran >= window {
+		// the run was long enough to count as healthy, so the backoff starts over
+		s.reset()
+	}
+
+	if failed {
+		now := time.Now()
+		crashes := s.crashes[:0]
+		for _, crash := range s.crashes {
+			if now.Sub(crash) < window {
+				crashes = append(crashes, crash)
+			}
+		}
+		s.crashes = append(crashes, now)
+
+		limit := command.CrashLoopCount
+		if limit == 0 {
+			limit = process.DefaultCrashLoopCount
+		}
+		if len(s.crashes) >= limit {
+			log.Logger.Error(fmt.Sprintf("Command %v is crash looping, it crashed %d times within %v. Giving up on restarting it until a file changes", command.Name, len(s.crashes), window))
+			return 0, false
+		}
+	}
+
+	if command.MaxRestarts > 0 && s.restarts >= command.MaxRestarts {
+		log.Logger.Error(fmt.Sprintf("Command %v was restarted %d times in a row. Giving up on restarting it until a file changes", command.Name, s.restarts))
+		return 0, false
+	}
+
+	delay := command.RestartDelay.Or(process.DefaultRestartDelay)
+	maxDelay := command.RestartMaxDelay.Or(process.DefaultRestartMaxDelay)
+	for i := 0; i < s.restarts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	s.restarts++
+	return min(delay, maxDelay), true
+}
@@ -0,0 +1,185 @@
+package scaffold
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var commonIgnore = []string{"*/.git/*"}
+
+func exists(dir string, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func ignore(patterns ...string) []string {
+	return append(append([]string(nil), commonIgnore...), patterns...)
+}
+
+// detectGo suggests running the main package and the tests of a Go module
+func detectGo(dir string, cwd string) []Command {
+	if !exists(dir, "go.mod") {
+		return nil
+	}
+
+	// vendored code only changes through go mod vendor, which also rewrites
+	// modules.txt
+	include := []string{"*/vendor/modules.txt"}
+
+	var commands []Command
+	if hasMainPackage(dir) {
+		commands = append(commands, Command{Name: "run", Command: "go run .", Cwd: cwd, Ignore: ignore("*/vendor/*", "*_test.go"), Include: include, Tags: []string{"go"}})
+	}
+	commands = append(commands, Command{Name: "test", Command: "go test ./...", Cwd: cwd, Ignore: ignore("*/vendor/*"), Include: include, Tags: []string{"go", "test"}})
+	return commands
+}
+
+var mainPackage = regexp.MustCompile(`(?m)^package main\b`)
+
+func hasMainPackage(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err == nil && mainPackage.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// scripts of a package.json that are worth running under dues
+var nodeScripts = []string{"dev", "start", "serve", "watch", "test", "build", "lint"}
+
+// detectNode suggests the common scripts of a package.json, run with the
+// package manager whose lock file is present
+func detectNode(dir string, cwd string) []Command {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var packageJSON struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &packageJSON); err != nil {
+		return nil
+	}
+
+	manager := "npm"
+	switch {
+	case exists(dir, "pnpm-lock.yaml"):
+		manager = "pnpm"
+	case exists(dir, "yarn.lock"):
+		manager = "yarn"
+	case exists(dir, "bun.lockb"):
+		manager = "bun"
+	}
+
+	var commands []Command
+	for _, script := range nodeScripts {
+		if _, found := packageJSON.Scripts[script]; !found {
+			continue
+		}
+		tags := []string{"node"}
+		if script == "test" {
+			tags = append(tags, "test")
+		}
+		commands = append(commands, Command{
+			Name:    script,
+			Command: manager + " run " + script,
+			Cwd:     cwd,
+			Ignore:  ignore("*/node_modules/*", "*/dist/*", "*/build/*", "*/coverage/*", "*/.next/*"),
+			// rewritten whenever dependencies are installed
+			Include: []string{"*/node_modules/.package-lock.json"},
+			Tags:    tags,
+		})
+	}
+	return commands
+}
+
+// detectRust suggests running and testing a cargo project
+func detectRust(dir string, cwd string) []Command {
+	if !exists(dir, "Cargo.toml") {
+		return nil
+	}
+	ignored := ignore("*/target/*")
+	return []Command{
+		{Name: "run", Command: "cargo run", Cwd: cwd, Ignore: ignored, Tags: []string{"rust"}},
+		{Name: "test", Command: "cargo test", Cwd: cwd, Ignore: ignored, Tags: []string{"rust", "test"}},
+	}
+}
+
+// detectPython suggests the development server of a django project
+func detectPython(dir string, cwd string) []Command {
+	if !exists(dir, "manage.py") {
+		return nil
+	}
+	return []Command{{
+		Name:    "runserver",
+		Command: "python manage.py runserver",
+		Cwd:     cwd,
+		Ignore:  ignore("*/__pycache__/*", "*.pyc", "*/.venv/*", "*/venv/*"),
+		Tags:    []string{"python"},
+	}}
+}
+
+// make targets that are worth running under dues
+var makeTargets = []string{"run", "dev", "serve", "start", "watch", "test", "build", "lint"}
+
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*:([^=]|$)`)
+
+// detectMake suggests the common targets of a Makefile
+func detectMake(dir string, cwd string) []Command {
+	file, err := os.Open(filepath.Join(dir, "Makefile"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	targets := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if match := makeTarget.FindStringSubmatch(scanner.Text()); match != nil {
+			targets[match[1]] = true
+		}
+	}
+
+	var commands []Command
+	for _, target := range makeTargets {
+		if targets[target] {
+			commands = append(commands, Command{Name: "make-" + target, Command: "make " + target, Cwd: cwd, Ignore: ignore(), Tags: []string{"make"}})
+		}
+	}
+	return commands
+}
+
+// detectProcfile suggests every process type of a Procfile
+func detectProcfile(dir string, cwd string) []Command {
+	file, err := os.Open(filepath.Join(dir, "Procfile"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var commands []Command
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, command, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(command) == "" {
+			continue
+		}
+		commands = append(commands, Command{Name: strings.TrimSpace(name), Command: strings.TrimSpace(command), Cwd: cwd, Ignore: ignore(), Tags: []string{"procfile"}})
+	}
+	return commands
+}
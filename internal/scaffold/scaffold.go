@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Command is a command suggested for a detected project
+type Command struct {
+	Name    string
+	Command string
+	Cwd     string
+	Ignore  []string
+	Include []string
+	Tags    []string
+}
+
+// detector suggests commands for the project in dir. cwd is the path of dir
+// relative to the directory dues init was run in
+type detector func(dir string, cwd string) []Command
+
+var detectors = []detector{
+	detectGo,
+	detectNode,
+	detectRust,
+	detectPython,
+	detectMake,
+	detectProcfile,
+}
+
+// directories that are never scanned for projects
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// Detect looks for known project files in dir and in each of its direct sub
+// directories and suggests commands for every project found. Commands found in
+// sub directories are prefixed with the directory name.
+func Detect(dir string) ([]Command, error) {
+	commands := detect(dir, ".")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || skippedDirs[name] {
+			continue
+		}
+		for _, command := range detect(filepath.Join(dir, name), name) {
+			command.Name = name + "-" + command.Name
+			commands = append(commands, command)
+		}
+	}
+
+	return unique(commands), nil
+}
+
+func detect(dir string, cwd string) []Command {
+	var commands []Command
+	for _, detect := range detectors {
+		commands = append(commands, detect(dir, cwd)...)
+	}
+	return commands
+}
+
+// unique renames commands sharing a name by numbering them
+func unique(commands []Command) []Command {
+	seen := make(map[string]int)
+	for i := range commands {
+		name := commands[i].Name
+		seen[name]++
+		if seen[name] > 1 {
+			commands[i].Name = name + "-" + strconv.Itoa(seen[name])
+		}
+	}
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 The Dues Authors
+*/
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Draft is the JSON Schema version generated schemas conform to
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schemer is implemented by types whose JSON representation cannot be derived
+// from their Go type, such as values that accept more than one JSON type
+type Schemer interface {
+	JSONSchema() map[string]any
+}
+
+var schemerType = reflect.TypeOf((*Schemer)(nil)).Elem()
+
+// Generate builds a JSON Schema describing the JSON encoding of v. Struct
+// fields are named after their json tags and unknown properties are not
+// allowed.
+func Generate(v any, id string, title string) map[string]any {
+	schema := forType(reflect.TypeOf(v))
+	schema["$schema"] = Draft
+	if id != "" {
+		schema["$id"] = id
+	}
+	if title != "" {
+		schema["title"] = title
+	}
+	return schema
+}
+
+func forType(t reflect.Type) map[string]any {
+	if t.Implements(schemerType) {
+		return reflect.Zero(t).Interface().(Schemer).JSONSchema()
+	}
+	if reflect.PointerTo(t).Implements(schemerType) {
+		return reflect.New(t).Interface().(Schemer).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return forType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": forType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": forType(t.Elem())}
+	case reflect.Struct:
+		return forStruct(t)
+	}
+	return map[string]any{}
+}
+
+func forStruct(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = forType(field.Type)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
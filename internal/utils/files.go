@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func IsDir(path string) bool {
@@ -16,6 +17,15 @@ func IsDir(path string) bool {
 	return fileInfo.IsDir()
 }
 
+// IsSubPath reports whether path is dir itself or is inside of it
+func IsSubPath(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
 func WalkSubdirectories(path string, callback func(string)) {
 	filepath.WalkDir(path, func(sub_path string, d fs.DirEntry, err error) error {
 		if err == nil && d.IsDir() {
@@ -4,6 +4,8 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 */
 package main
 
+//go:generate go run . schema --output dues.schema.json
+
 import "github.com/anjolaoluwaakindipe/dues/cmd"
 
 func main() {
@@ -6,87 +6,105 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"sync"
+	"path/filepath"
 	"syscall"
 
 	"github.com/anjolaoluwaakindipe/dues/internal/config"
-	"github.com/anjolaoluwaakindipe/dues/internal/debounce"
-	"github.com/anjolaoluwaakindipe/dues/internal/filewatcher"
-	"github.com/anjolaoluwaakindipe/dues/internal/process"
-	"github.com/anjolaoluwaakindipe/dues/internal/runner"
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
 )
 
 type DuesConfig struct {
-	Commands   []string
+	// Commands, groups and aliases to run
+	Commands []string
+	// Run every command with at least one of these tags
+	Tags []string
+	// Run every command in the config
+	All        bool
 	ConfigPath string
+	// Profile to apply to the commands. Defaults to the DUES_PROFILE
+	// environment variable
+	Profile string
+	// How the exit status is derived from failed runs, ExitStatusFirst or
+	// ExitStatusMax. Defaults to ExitStatusFirst
+	ExitStatus string
 }
 
-func RunDues(duesConfig DuesConfig) error {
-	commands := duesConfig.Commands
-	configPath := duesConfig.ConfigPath
+// ProfileEnv is the environment variable holding the default profile
+const ProfileEnv = "DUES_PROFILE"
 
-	// Read Json file to get all commands available
-	var userConfig config.UserConfig
-	err := config.ReadConfigFile(configPath, &userConfig)
+// resolveConfigPath returns the absolute path of the config file to use. When
+// no path is given the current directory and its parents are searched for one
+func resolveConfigPath(configPath string) (string, error) {
+	if configPath != "" {
+		return filepath.Abs(configPath)
+	}
 
+	wd, err := os.Getwd()
 	if err != nil {
-		return errors.New(fmt.Sprintf("An error occured while opening the config file: %v", err))
+		return "", fmt.Errorf("could not get the current directory: %w", err)
 	}
 
-	userConfig.Process(configPath)
-
-	var commandList []*process.Command
-
-	for _, currCommand := range commands {
-		selectedCommand, err := userConfig.GetCommand(currCommand)
-		if err != nil {
-			return err
-		}
+	return config.FindConfigFile(wd)
+}
 
-		commandList = append(commandList, selectedCommand)
+// resolveProfile returns the profile to use, falling back to ProfileEnv
+func resolveProfile(profile string) string {
+	if profile != "" {
+		return profile
 	}
+	return os.Getenv(ProfileEnv)
+}
 
+// loadConfig loads the config file at configPath with everything it extends
+// and includes, applies profile and processes the result
+func loadConfig(configPath string, profile string) (*config.UserConfig, error) {
+	userConfig, err := config.Load(configPath)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("An error occured while opening the config file: %v", err))
+	}
 
-	var wg sync.WaitGroup
-  backgroundCtx, cancel := context.WithCancel(context.Background())
-  var commandListErr error = nil 
-	for _, currCommand := range commandList {
-    sigs := make(chan os.Signal, 1)
-    signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-		watcher, err := filewatcher.NewDefaultWatcher()
-		if err != nil {
-      cancel()
-			commandListErr = fmt.Errorf("could not initialize file watcher: %w", err)
-      break
-		}
+	if err := userConfig.ApplyProfile(profile); err != nil {
+		return nil, err
+	}
 
-		d := debounce.NewDebouncer()
+	if err := userConfig.Process(configPath); err != nil {
+		return nil, fmt.Errorf("invalid config file %v: %w", configPath, err)
+	}
 
-		// gorountine to detect file changes, creation, and deletion and perform commands repectively
-		commandRunner, err := runner.NewDuesCommandRunner(
-			runner.WithCommand(currCommand),
-			runner.WithWatcher(watcher),
-			runner.WithDebouncer(d),
-		)
+	return userConfig, nil
+}
 
-		if err != nil {
-      cancel()
-			commandListErr = fmt.Errorf("an error occurred initializing runner: %w", err)
-      break
-		}
+func RunDues(duesConfig DuesConfig) error {
+	configPath, err := resolveConfigPath(duesConfig.ConfigPath)
+	if err != nil {
+		return err
+	}
+	log.Logger.Info(fmt.Sprintf("Using config file %v", configPath))
 
-		wg.Add(1)
-		go commandRunner.CommandLoop(&wg, sigs, backgroundCtx)
+	selection := config.Selection{
+		Names: duesConfig.Commands,
+		Tags:  duesConfig.Tags,
+		All:   duesConfig.All,
+	}
+	if selection.IsEmpty() {
+		return errors.New("no command selected, pass a command, group or alias name, --tag or --all")
+	}
 
+	exits, err := newExitStatus(duesConfig.ExitStatus, statusFields)
+	if err != nil {
+		return err
 	}
 
-	wg.Wait()
-  cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-  if commandListErr != nil {
-    return commandListErr
-  }
+	profile := resolveProfile(duesConfig.Profile)
+	if profile != "" {
+		log.Logger.Info(fmt.Sprintf("Using profile %v", profile))
+	}
 
-	return nil
+	if err := newSession(configPath, profile, selection, exits).run(ctx); err != nil {
+		return err
+	}
+	return exits.err()
 }
@@ -0,0 +1,117 @@
+package dues
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// Ways the exit status of dues can be derived from the runs of its commands
+const (
+	// Exit with the code of the first run that failed
+	ExitStatusFirst = "first"
+	// Exit with the highest code of every run that failed
+	ExitStatusMax = "max"
+)
+
+// ExitError is returned by RunDues when a command failed while dues was
+// running. Code is the status dues should exit with
+type ExitError struct {
+	Code    int
+	Command string
+	// Field of the command that failed, such as command, preCommand or step 1
+	Field string
+	Exit  process.Exit
+}
+
+func (e *ExitError) Error() string {
+	command := e.Command
+	if e.Field != "command" {
+		command += " " + e.Field
+	}
+	return fmt.Sprintf("Command %v %v, exiting with status %d", command, e.Exit, e.Code)
+}
+
+// statusFields are the fields of a command whose runs make up the exit status
+// of dues. Steps and the commands of on rules are left out as they run again
+// on the next change, like restarts of the command do
+var statusFields = []string{"command", "preCommand", "postCommand"}
+
+// exitStatus aggregates the failed runs of every command of a session. Only
+// the last run of a field counts, so a failure is forgotten once a restart of
+// the command succeeds
+type exitStatus struct {
+	mutex  sync.Mutex
+	mode   string
+	fields []string
+	// last run of every command field that failed, by command and field
+	failures map[string]failure
+	runs     int
+}
+
+// failure is a failed run and the number of runs recorded before it
+type failure struct {
+	err *ExitError
+	run int
+}
+
+func newExitStatus(mode string, fields []string) (*exitStatus, error) {
+	switch mode {
+	case "":
+		mode = ExitStatusFirst
+	case ExitStatusFirst, ExitStatusMax:
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown exit status '%v', expected %v or %v", mode, ExitStatusFirst, ExitStatusMax))
+	}
+	return &exitStatus{mode: mode, fields: fields, failures: make(map[string]failure)}, nil
+}
+
+// record takes the run of field of command into account, unless field is not
+// one of the fields of the exit status. It is safe to call from several
+// runners at once
+func (es *exitStatus) record(command *process.Command, field string, exit process.Exit) {
+	if !slices.Contains(es.fields, field) {
+		return
+	}
+
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	key := command.Name + " " + field
+	es.runs++
+	if !exit.Failed() {
+		delete(es.failures, key)
+		return
+	}
+	es.failures[key] = failure{
+		err: &ExitError{Code: exit.Code, Command: command.Name, Field: field, Exit: exit},
+		run: es.runs,
+	}
+}
+
+// err returns the failure dues should exit with, or nil when the last run of
+// every field succeeded
+func (es *exitStatus) err() error {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	var chosen *failure
+	for _, f := range es.failures {
+		f := f
+		switch {
+		case chosen == nil:
+		case es.mode == ExitStatusFirst && f.run > chosen.run:
+			continue
+		case es.mode == ExitStatusMax && (f.err.Code < chosen.err.Code || f.err.Code == chosen.err.Code && f.run > chosen.run):
+			continue
+		}
+		chosen = &f
+	}
+	if chosen == nil {
+		return nil
+	}
+	return chosen.err
+}
@@ -0,0 +1,72 @@
+package dues
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// run is one reported run of a command field
+type run struct {
+	command string
+	field   string
+	code    int
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		runs     []run
+		wantCode int
+		wantRun  string
+	}{
+		{"every run succeeded", ExitStatusFirst, []run{{"api", "command", 0}, {"web", "preCommand", 0}}, 0, ""},
+		{"failed command", ExitStatusFirst, []run{{"api", "command", 2}}, 2, "api command"},
+		{"failed rule", ExitStatusFirst, []run{{"api", "on[0]", 1}, {"api", "command", 0}}, 0, ""},
+		{"failed step", ExitStatusFirst, []run{{"api", "step 1", 1}}, 0, ""},
+		{"restart succeeded", ExitStatusFirst, []run{{"api", "command", 1}, {"api", "command", 1}, {"api", "command", 0}}, 0, ""},
+		{"restart failed", ExitStatusFirst, []run{{"api", "command", 0}, {"api", "command", 3}}, 3, "api command"},
+		{"first failure", ExitStatusFirst, []run{{"api", "postCommand", 1}, {"web", "command", 7}}, 1, "api postCommand"},
+		{"max failure", ExitStatusMax, []run{{"api", "postCommand", 1}, {"web", "command", 7}, {"db", "command", 2}}, 7, "web command"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			es, err := newExitStatus(test.mode, statusFields)
+			if err != nil {
+				t.Fatalf("newExitStatus(%q) returned error: %v", test.mode, err)
+			}
+			for _, r := range test.runs {
+				es.record(&process.Command{Name: r.command}, r.field, process.Exit{Code: r.code})
+			}
+
+			err = es.err()
+			if test.wantCode == 0 {
+				if err != nil {
+					t.Errorf("err() = %v, want nil", err)
+				}
+				return
+			}
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("err() = %v, want an *ExitError", err)
+			}
+			if exitErr.Code != test.wantCode || exitErr.Command+" "+exitErr.Field != test.wantRun {
+				t.Errorf("err() = %v of %v %v, want %v of %v", exitErr.Code, exitErr.Command, exitErr.Field, test.wantCode, test.wantRun)
+			}
+		})
+	}
+}
+
+func TestExitStatusIgnoresStoppedRuns(t *testing.T) {
+	es, err := newExitStatus(ExitStatusFirst, statusFields)
+	if err != nil {
+		t.Fatalf("newExitStatus returned error: %v", err)
+	}
+	es.record(&process.Command{Name: "api"}, "command", process.Exit{Code: 143, Signal: "SIGTERM", Stopped: true})
+	if err := es.err(); err != nil {
+		t.Errorf("err() = %v after a run stopped by dues, want nil", err)
+	}
+}
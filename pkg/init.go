@@ -0,0 +1,74 @@
+package dues
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/config"
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/scaffold"
+)
+
+// InitConfig writes a starter config file for the project in dir. Commands
+// are suggested from the project files found in dir and its direct sub
+// directories, such as go.mod, package.json, Cargo.toml, Makefile and
+// Procfile. The format of the file is picked from the extension of output,
+// which is resolved against dir. An existing file is only replaced when force
+// is true.
+func InitConfig(dir string, output string, force bool) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("%v already exists, use --force to overwrite it", output)
+	}
+
+	suggested, err := scaffold.Detect(dir)
+	if err != nil {
+		return err
+	}
+	if len(suggested) == 0 {
+		return errors.New(fmt.Sprintf("Could not detect any project in %v, expected files such as go.mod, package.json, Cargo.toml, Makefile or Procfile", dir))
+	}
+
+	// only the fields with a value are written so that the file stays short
+	commands := make(map[string]any, len(suggested))
+	var names []string
+	for _, command := range suggested {
+		fields := map[string]any{"command": command.Command}
+		if command.Cwd != "." {
+			fields["cwd"] = command.Cwd
+		}
+		if len(command.Ignore) > 0 {
+			fields["ignore"] = command.Ignore
+		}
+		if len(command.Include) > 0 {
+			fields["include"] = command.Include
+		}
+		if len(command.Tags) > 0 {
+			fields["tags"] = command.Tags
+		}
+		commands[command.Name] = fields
+		names = append(names, command.Name)
+	}
+
+	data, err := config.EncodeConfigFile(output, map[string]any{"commands": commands})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+
+	log.Logger.Info(fmt.Sprintf("Wrote %v with commands %v", output, strings.Join(names, ", ")))
+	return nil
+}
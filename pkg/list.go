@@ -0,0 +1,74 @@
+package dues
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+	"github.com/anjolaoluwaakindipe/dues/internal/utils"
+)
+
+// ListCommands writes a table of every command, group and alias in the config file
+// at configPath, or the one found from the current directory when it is
+// empty, along with the file each command was defined in. The commands are
+// shown with profile applied
+func ListCommands(configPath string, profile string, w io.Writer) error {
+	configPath, err := resolveConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+
+	userConfig, err := loadConfig(configPath, resolveProfile(profile))
+	if err != nil {
+		return err
+	}
+
+	wd, _ := os.Getwd()
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(wd, path); err == nil && wd != "" {
+			return rel
+		}
+		return path
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "COMMAND\tTAGS\tDEFINED IN\tRUNS")
+	for _, name := range utils.SortedKeys(userConfig.Commands) {
+		command := userConfig.Commands[name]
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\n", name, strings.Join(command.Tags, ","), relative(command.Source), runs(command))
+	}
+
+	if len(userConfig.Groups) > 0 {
+		fmt.Fprintln(table)
+		fmt.Fprintln(table, "GROUP\tCOMMANDS")
+		for _, name := range utils.SortedKeys(userConfig.Groups) {
+			fmt.Fprintf(table, "%v\t%v\n", name, strings.Join(userConfig.Groups[name], ","))
+		}
+	}
+
+	if len(userConfig.Aliases) > 0 {
+		fmt.Fprintln(table)
+		fmt.Fprintln(table, "ALIAS\tFOR")
+		for _, name := range utils.SortedKeys(userConfig.Aliases) {
+			fmt.Fprintf(table, "%v\t%v\n", name, userConfig.Aliases[name])
+		}
+	}
+
+	return table.Flush()
+}
+
+// runs describes what command runs, joining its steps if it has any
+func runs(command *process.Command) string {
+	if len(command.Steps) == 0 {
+		return command.Command.String()
+	}
+	steps := make([]string, len(command.Steps))
+	for i, step := range command.Steps {
+		steps[i] = step.String()
+	}
+	return strings.Join(steps, " && ")
+}
@@ -0,0 +1,376 @@
+package dues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/config"
+	"github.com/anjolaoluwaakindipe/dues/internal/debounce"
+	"github.com/anjolaoluwaakindipe/dues/internal/filewatcher"
+	"github.com/anjolaoluwaakindipe/dues/internal/input"
+	"github.com/anjolaoluwaakindipe/dues/internal/log"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+	"github.com/anjolaoluwaakindipe/dues/internal/runner"
+)
+
+// activeCommand is a command whose runner is currently running
+type activeCommand struct {
+	command *process.Command
+	runner  *runner.DuesCommandRunner
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// stop cancels the runner of the command and waits for it to finish its clean up
+func (ac *activeCommand) stop() {
+	ac.cancel()
+	ac.wg.Wait()
+}
+
+// session keeps track of the runners started for a selection of commands and
+// reconciles them whenever the config file changes
+type session struct {
+	configPath string
+	profile    string
+	// every config file the current config was loaded from
+	files     []string
+	selection config.Selection
+	active    map[string]*activeCommand
+	order     []string
+	exits     *exitStatus
+	// names of the commands whose main command was restarted by their runner
+	// and that are yet to be handled, restartNotify is signalled whenever one
+	// is added
+	restartMutex  sync.Mutex
+	restarted     []string
+	restartNotify chan struct{}
+	// forwards terminal input to the commands that accept it
+	input *input.Router
+}
+
+func newSession(configPath string, profile string, selection config.Selection, exits *exitStatus) *session {
+	return &session{
+		configPath:    configPath,
+		profile:       profile,
+		selection:     selection,
+		active:        make(map[string]*activeCommand),
+		exits:         exits,
+		restartNotify: make(chan struct{}, 1),
+		input:         input.NewRouter(os.Stdin),
+	}
+}
+
+// load reads and processes the config file and returns the selected commands
+func (s *session) load() ([]*process.Command, error) {
+	userConfig, err := loadConfig(s.configPath, s.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := userConfig.SelectCommands(s.selection)
+	if err != nil {
+		return nil, err
+	}
+
+	s.files = userConfig.Files()
+	return commands, nil
+}
+
+// watchFiles adds the directories of the config files to watcher. The
+// directories are watched so that editors replacing a file on save are
+// noticed as well
+func (s *session) watchFiles(watcher filewatcher.Watcher) error {
+	for _, file := range s.files {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			return fmt.Errorf("could not watch config file %v: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// start launches a runner for command
+func (s *session) start(command *process.Command) error {
+	watcher, err := filewatcher.NewDefaultWatcher()
+	if err != nil {
+		return fmt.Errorf("could not initialize file watcher: %w", err)
+	}
+
+	dependencies := make(map[string]runner.Runner, len(command.DependsOn))
+	for _, name := range command.DependsOn {
+		if dependency, running := s.active[name]; running {
+			dependencies[name] = dependency.runner
+		}
+	}
+
+	// gorountine to detect file changes, creation, and deletion and perform commands repectively
+	commandRunner, err := runner.NewDuesCommandRunner(
+		runner.WithCommand(command),
+		runner.WithWatcher(watcher),
+		runner.WithDebouncer(debounce.NewDebouncer()),
+		runner.WithIgnoredFiles(s.files...),
+		runner.WithExitHandler(s.exits.record),
+		runner.WithDependencies(dependencies),
+		runner.WithInput(s.input),
+		runner.WithRestartHandler(s.notifyRestart),
+	)
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("an error occurred initializing runner: %w", err)
+	}
+
+	// runners are not cancelled along with the session, stopAll stops them in
+	// dependency order instead
+	commandCtx, cancel := context.WithCancel(context.Background())
+	active := &activeCommand{command: command, runner: commandRunner, cancel: cancel}
+	active.wg.Add(1)
+	go commandRunner.CommandLoop(&active.wg, commandCtx)
+
+	s.active[command.Name] = active
+	s.order = append(s.order, command.Name)
+	return nil
+}
+
+// stop shuts down the runner of the named command
+func (s *session) stop(name string) {
+	active, exists := s.active[name]
+	if !exists {
+		return
+	}
+	active.stop()
+	delete(s.active, name)
+
+	for i, current := range s.order {
+		if current == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// logStatus logs the lifecycle state of every running command along with how
+// many times it was restarted
+func (s *session) logStatus() {
+	for _, name := range s.order {
+		commandRunner := s.active[name].runner
+		log.Logger.Info(fmt.Sprintf("Command %v is %v, restart count %d", name, commandRunner.State(), commandRunner.RestartCount()))
+	}
+}
+
+// stopAll shuts down every runner of the session. Commands are stopped
+// concurrently, except that a command is only stopped once every command that
+// depends on it has stopped
+func (s *session) stopAll() {
+	s.logStatus()
+	stopped := make(map[string]chan struct{}, len(s.active))
+	for name := range s.active {
+		stopped[name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for name, active := range s.active {
+		wg.Add(1)
+		go func(name string, active *activeCommand) {
+			defer wg.Done()
+			for _, dependent := range s.dependents(name) {
+				<-stopped[dependent]
+			}
+			active.stop()
+			close(stopped[name])
+		}(name, active)
+	}
+	wg.Wait()
+	s.active = make(map[string]*activeCommand)
+	s.order = nil
+}
+
+// dependents returns the names of the running commands that depend on the
+// named command
+func (s *session) dependents(name string) []string {
+	var dependents []string
+	for _, dependent := range s.order {
+		if slices.Contains(s.active[dependent].command.DependsOn, name) {
+			dependents = append(dependents, dependent)
+		}
+	}
+	return dependents
+}
+
+// notifyRestart records that the main command of command was restarted by its
+// runner. It never blocks, so that runners are not held up by the session
+func (s *session) notifyRestart(command *process.Command) {
+	s.restartMutex.Lock()
+	if !slices.Contains(s.restarted, command.Name) {
+		s.restarted = append(s.restarted, command.Name)
+	}
+	s.restartMutex.Unlock()
+
+	select {
+	case s.restartNotify <- struct{}{}:
+	default:
+		// the session has yet to handle a previous notification, which
+		// covers this restart as well
+	}
+}
+
+// takeRestarted returns the names of the commands restarted since it was
+// last called
+func (s *session) takeRestarted() []string {
+	s.restartMutex.Lock()
+	defer s.restartMutex.Unlock()
+
+	restarted := s.restarted
+	s.restarted = nil
+	return restarted
+}
+
+// restartDependents restarts the running commands that asked to be restarted
+// along with the named command
+func (s *session) restartDependents(name string) {
+	for _, dependent := range s.dependents(name) {
+		active := s.active[dependent]
+		if active.command.RestartWithDependencies {
+			log.Logger.Info(fmt.Sprintf("Restarting command %v because %v restarted", dependent, name))
+			active.runner.Restart()
+		}
+	}
+}
+
+// reconcile brings the running commands in line with commands, which are
+// ordered so that dependencies come before their dependents. Unchanged
+// commands keep running, changed ones are restarted along with the dependents
+// that asked for it, and removed ones are stopped
+func (s *session) reconcile(commands []*process.Command) error {
+	wanted := make(map[string]*process.Command, len(commands))
+	for _, command := range commands {
+		wanted[command.Name] = command
+	}
+
+	restart := make(map[string]bool)
+	for _, command := range commands {
+		active, running := s.active[command.Name]
+		if !running {
+			continue
+		}
+		if !active.command.Equal(command) {
+			log.Logger.Info(fmt.Sprintf("Command %v changed in the config, restarting it", command.Name))
+			restart[command.Name] = true
+			continue
+		}
+		if !command.RestartWithDependencies {
+			continue
+		}
+		for _, dependency := range command.DependsOn {
+			if restart[dependency] {
+				log.Logger.Info(fmt.Sprintf("Restarting command %v because %v restarts", command.Name, dependency))
+				restart[command.Name] = true
+				break
+			}
+		}
+	}
+
+	// dependents are stopped before the commands they depend on
+	order := append([]string(nil), s.order...)
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		if _, exists := wanted[name]; !exists {
+			log.Logger.Info(fmt.Sprintf("Command %v was removed from the config, stopping it", name))
+			s.stop(name)
+			continue
+		}
+		if restart[name] {
+			s.stop(name)
+		}
+	}
+
+	for _, command := range commands {
+		if _, running := s.active[command.Name]; running {
+			// unchanged commands keep their runner
+			continue
+		}
+		if err := s.start(command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reload re-reads the config file and reconciles the running commands with
+// it. An invalid config is reported and the current commands keep running
+func (s *session) reload(watcher filewatcher.Watcher) {
+	log.Logger.Info(fmt.Sprintf("Config file %v changed, reloading", s.configPath))
+
+	commands, err := s.load()
+	if err != nil {
+		log.Logger.Error(fmt.Sprintf("Keeping the previous config, the new one is invalid: %v", err))
+		return
+	}
+
+	if err := s.watchFiles(watcher); err != nil {
+		log.Logger.Error(err.Error())
+	}
+
+	if err := s.reconcile(commands); err != nil {
+		log.Logger.Error(fmt.Sprintf("An error occured while applying the new config: %v", err))
+	}
+}
+
+// run starts the selected commands and watches the config file until ctx is
+// cancelled, at which point every command is shut down
+func (s *session) run(ctx context.Context) error {
+	commands, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := filewatcher.NewDefaultWatcher()
+	if err != nil {
+		return fmt.Errorf("could not initialize config file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := s.watchFiles(watcher); err != nil {
+		return err
+	}
+
+	defer s.stopAll()
+	if err := s.reconcile(commands); err != nil {
+		return err
+	}
+
+	reloadTimer := time.NewTimer(0)
+	<-reloadTimer.C
+	events := watcher.Events()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("config file watcher stopped unexpectedly")
+			}
+			if !slices.Contains(s.files, filepath.Clean(event.Name())) {
+				continue
+			}
+			reloadTimer.Reset(200 * time.Millisecond)
+		case err, ok := <-watcher.Errors():
+			if !ok {
+				return errors.New("config file watcher stopped unexpectedly")
+			}
+			log.Logger.Error(fmt.Sprintf("An error occured while watching the config file: %v", err))
+		case <-reloadTimer.C:
+			s.reload(watcher)
+		case <-s.restartNotify:
+			for _, name := range s.takeRestarted() {
+				s.restartDependents(name)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package dues
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/anjolaoluwaakindipe/dues/internal/config"
+	"github.com/anjolaoluwaakindipe/dues/internal/process"
+)
+
+// ShowCommands writes the effective definition of the named commands and
+// groups as JSON, after profile has been applied and variables have been
+// expanded. Every command is shown when no name is given
+func ShowCommands(configPath string, profile string, names []string, w io.Writer) error {
+	configPath, err := resolveConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+
+	userConfig, err := loadConfig(configPath, resolveProfile(profile))
+	if err != nil {
+		return err
+	}
+
+	commands, err := userConfig.SelectCommands(config.Selection{Names: names, All: len(names) == 0})
+	if err != nil {
+		return err
+	}
+
+	effective := make(map[string]*process.Command, len(commands))
+	for _, command := range commands {
+		effective[command.Name] = command
+	}
+
+	data, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
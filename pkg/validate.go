@@ -0,0 +1,64 @@
+package dues
+
+import "github.com/anjolaoluwaakindipe/dues/internal/config"
+
+// ValidateConfig strictly checks the config file at configPath, or the one
+// found from the current directory when it is empty. Unknown keys, invalid
+// commands, missing cwd directories, executables that are not on PATH and
+// invalid patterns are all reported. The path of the checked config file is
+// returned along with every problem found. The commands are checked with
+// profile applied.
+func ValidateConfig(configPath string, profile string) (string, []error) {
+	configPath, err := resolveConfigPath(configPath)
+	if err != nil {
+		return "", []error{err}
+	}
+
+	var problems []error
+
+	if _, err := config.LoadStrict(configPath); err != nil {
+		problems = append(problems, flatten(err)...)
+	}
+
+	// the config is loaded again leniently so that the remaining checks still
+	// run when it contains unknown keys
+	userConfig, err := config.Load(configPath)
+	if err != nil {
+		if len(problems) == 0 {
+			problems = append(problems, flatten(err)...)
+		}
+		return configPath, problems
+	}
+
+	if err := userConfig.ApplyProfile(resolveProfile(profile)); err != nil {
+		problems = append(problems, flatten(err)...)
+		return configPath, problems
+	}
+
+	if err := userConfig.Process(configPath); err != nil {
+		problems = append(problems, flatten(err)...)
+	}
+
+	if err := userConfig.Validate(); err != nil {
+		problems = append(problems, flatten(err)...)
+	}
+
+	return configPath, problems
+}
+
+// flatten splits errors joined with errors.Join into their parts
+func flatten(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, inner := range joined.Unwrap() {
+		errs = append(errs, flatten(inner)...)
+	}
+	return errs
+}